@@ -27,6 +27,12 @@ type RequestResult struct {
 	Err error
 }
 
+// requestChannel receives the responses of one request, removed is closed once nobody reads them anymore
+type requestChannel struct {
+	results chan RequestResult
+	removed chan struct{}
+}
+
 type Client struct {
 	hostname string
 	port     uint
@@ -174,8 +180,21 @@ func (c *Client) handleResponse(msg map[string]interface{}) {
 }
 
 func (c *Client) sendRequest(request map[string]interface{}) (map[string]interface{}, error) {
+	requestId, err := c.send(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.removeChannel(requestId)
+
+	return c.getResponse(requestId)
+}
+
+// send writes the request without waiting for the response, the responses are received on the channel of the returned requestId
+func (c *Client) send(request map[string]interface{}) (int64, error) {
 	if !c.connected {
-		return nil, e.ClientNotConnected()
+		return 0, e.ClientNotConnected()
 	}
 
 	requestId := int64(float64(rand.Int()))
@@ -185,32 +204,38 @@ func (c *Client) sendRequest(request map[string]interface{}) (map[string]interfa
 	data, err := json.Marshal(request)
 
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	c.channels.Store(requestId, make(chan RequestResult))
+	c.channels.Store(requestId, &requestChannel{
+		results: make(chan RequestResult),
+		removed: make(chan struct{}),
+	})
 
 	err = c.ws.Write(c.ctx, websocket.MessageText, data)
 
 	if err != nil {
+		c.removeChannel(requestId)
+
 		if c.panicOnConnectionError && c.connected {
 			panic(err.Error())
 		}
 
-		return nil, err
+		return 0, err
 	}
 
-	defer func() {
-		close(c.getChannel(requestId))
-		c.channels.Delete(requestId)
-	}()
-
-	return c.getResponse(requestId)
+	return requestId, nil
 }
 
 func (c *Client) getResponse(requestId int64) (map[string]interface{}, error) {
+	var results chan RequestResult
+
+	if channel := c.getChannel(requestId); channel != nil {
+		results = channel.results
+	}
+
 	select {
-	case res := <-c.getChannel(requestId):
+	case res := <-results:
 		if res.Err != nil {
 			return nil, res.Err
 		}
@@ -227,14 +252,27 @@ func (c *Client) getResponse(requestId int64) (map[string]interface{}, error) {
 	}
 }
 
-func (c *Client) getChannel(requestId int64) chan RequestResult {
+// removeChannel stops receiving responses of the request, the results channel is never closed because the read loop may still send on it
+func (c *Client) removeChannel(requestId int64) {
+	channel, have := c.channels.LoadAndDelete(requestId)
+
+	if !have {
+		return
+	}
+
+	if ch, ok := channel.(*requestChannel); ok {
+		close(ch.removed)
+	}
+}
+
+func (c *Client) getChannel(requestId int64) *requestChannel {
 	channel, have := c.channels.Load(requestId)
 
 	if !have {
 		return nil
 	}
 
-	ch, ok := channel.(chan RequestResult)
+	ch, ok := channel.(*requestChannel)
 
 	if !ok {
 		return nil
@@ -12,6 +12,7 @@ import (
 	"github.com/lucasl0st/InfiniteDB/models/response"
 	"math/rand"
 	"nhooyr.io/websocket"
+	"time"
 )
 
 func (c *Client) ShutdownServer() error {
@@ -234,6 +235,128 @@ func (c *Client) GetFromDatabaseTable(name string, tableName string, request req
 	return getFromDatabaseTableResponse, nil
 }
 
+func (c *Client) CountFromDatabaseTable(name string, tableName string, request request.Request) (response.CountFromDatabaseTableResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.CountFromDatabaseTableMethod
+	r["name"] = name
+	r["tableName"] = tableName
+	r["request"] = request
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.CountFromDatabaseTableResponse{}, err
+	}
+
+	var countFromDatabaseTableResponse response.CountFromDatabaseTableResponse
+
+	err = mapToStruct(res, &countFromDatabaseTableResponse)
+
+	if err != nil {
+		return response.CountFromDatabaseTableResponse{}, err
+	}
+
+	return countFromDatabaseTableResponse, nil
+}
+
+func (c *Client) ExistsInDatabaseTable(name string, tableName string, request request.Request) (response.ExistsInDatabaseTableResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.ExistsInDatabaseTableMethod
+	r["name"] = name
+	r["tableName"] = tableName
+	r["request"] = request
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.ExistsInDatabaseTableResponse{}, err
+	}
+
+	var existsInDatabaseTableResponse response.ExistsInDatabaseTableResponse
+
+	err = mapToStruct(res, &existsInDatabaseTableResponse)
+
+	if err != nil {
+		return response.ExistsInDatabaseTableResponse{}, err
+	}
+
+	return existsInDatabaseTableResponse, nil
+}
+
+func (c *Client) OpenCursor(name string, tableName string, request request.Request, ttl time.Duration) (response.OpenCursorResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.OpenCursorMethod
+	r["name"] = name
+	r["tableName"] = tableName
+	r["request"] = request
+	r["ttl"] = ttl.Seconds()
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.OpenCursorResponse{}, err
+	}
+
+	var openCursorResponse response.OpenCursorResponse
+
+	err = mapToStruct(res, &openCursorResponse)
+
+	if err != nil {
+		return response.OpenCursorResponse{}, err
+	}
+
+	return openCursorResponse, nil
+}
+
+func (c *Client) FetchCursor(cursorId string) (response.FetchCursorResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.FetchCursorMethod
+	r["cursorId"] = cursorId
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.FetchCursorResponse{}, err
+	}
+
+	var fetchCursorResponse response.FetchCursorResponse
+
+	err = mapToStruct(res, &fetchCursorResponse)
+
+	if err != nil {
+		return response.FetchCursorResponse{}, err
+	}
+
+	return fetchCursorResponse, nil
+}
+
+func (c *Client) CloseCursor(cursorId string) (response.CloseCursorResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.CloseCursorMethod
+	r["cursorId"] = cursorId
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.CloseCursorResponse{}, err
+	}
+
+	var closeCursorResponse response.CloseCursorResponse
+
+	err = mapToStruct(res, &closeCursorResponse)
+
+	if err != nil {
+		return response.CloseCursorResponse{}, err
+	}
+
+	return closeCursorResponse, nil
+}
+
 func (c *Client) InsertToDatabaseTable(name string, tableName string, object map[string]json.RawMessage) (response.InsertToDatabaseTableResponse, error) {
 	r := make(map[string]interface{})
 
@@ -259,6 +382,31 @@ func (c *Client) InsertToDatabaseTable(name string, tableName string, object map
 	return insertToDatabaseTableResponse, nil
 }
 
+func (c *Client) BulkInsertToDatabaseTable(name string, tableName string, objects []map[string]json.RawMessage) (response.BulkInsertToDatabaseTableResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.BulkInsertToDatabaseTableMethod
+	r["name"] = name
+	r["tableName"] = tableName
+	r["objects"] = objects
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.BulkInsertToDatabaseTableResponse{}, err
+	}
+
+	var bulkInsertToDatabaseTableResponse response.BulkInsertToDatabaseTableResponse
+
+	err = mapToStruct(res, &bulkInsertToDatabaseTableResponse)
+
+	if err != nil {
+		return response.BulkInsertToDatabaseTableResponse{}, err
+	}
+
+	return bulkInsertToDatabaseTableResponse, nil
+}
+
 func (c *Client) RemoveFromDatabaseTable(name string, tableName string, request request.Request) (response.RemoveFromDatabaseTableResponse, error) {
 	r := make(map[string]interface{})
 
@@ -309,6 +457,156 @@ func (c *Client) UpdateInDatabaseTable(name string, tableName string, object map
 	return updateInDatabaseTableResponse, nil
 }
 
+func (c *Client) UpsertToDatabaseTable(name string, tableName string, object map[string]interface{}) (response.UpsertToDatabaseTableResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.UpsertToDatabaseTableMethod
+	r["name"] = name
+	r["tableName"] = tableName
+	r["object"] = object
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.UpsertToDatabaseTableResponse{}, err
+	}
+
+	var upsertToDatabaseTableResponse response.UpsertToDatabaseTableResponse
+
+	err = mapToStruct(res, &upsertToDatabaseTableResponse)
+
+	if err != nil {
+		return response.UpsertToDatabaseTableResponse{}, err
+	}
+
+	return upsertToDatabaseTableResponse, nil
+}
+
+func (c *Client) UpdateWhere(name string, tableName string, query request.Query, update request.Update) (response.UpdateWhereInDatabaseTableResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.UpdateWhereMethod
+	r["name"] = name
+	r["tableName"] = tableName
+	r["query"] = query
+	r["update"] = update
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.UpdateWhereInDatabaseTableResponse{}, err
+	}
+
+	var updateWhereInDatabaseTableResponse response.UpdateWhereInDatabaseTableResponse
+
+	err = mapToStruct(res, &updateWhereInDatabaseTableResponse)
+
+	if err != nil {
+		return response.UpdateWhereInDatabaseTableResponse{}, err
+	}
+
+	return updateWhereInDatabaseTableResponse, nil
+}
+
+func (c *Client) CompactDatabaseTable(name string, tableName string) (response.CompactDatabaseTableResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.CompactDatabaseTableMethod
+	r["name"] = name
+	r["tableName"] = tableName
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.CompactDatabaseTableResponse{}, err
+	}
+
+	var compactDatabaseTableResponse response.CompactDatabaseTableResponse
+
+	err = mapToStruct(res, &compactDatabaseTableResponse)
+
+	if err != nil {
+		return response.CompactDatabaseTableResponse{}, err
+	}
+
+	return compactDatabaseTableResponse, nil
+}
+
+func (c *Client) AlterTable(name string, tableName string, changes []request.AlterTableChange) (response.AlterTableResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.AlterTableMethod
+	r["name"] = name
+	r["tableName"] = tableName
+	r["changes"] = changes
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.AlterTableResponse{}, err
+	}
+
+	var alterTableResponse response.AlterTableResponse
+
+	err = mapToStruct(res, &alterTableResponse)
+
+	if err != nil {
+		return response.AlterTableResponse{}, err
+	}
+
+	return alterTableResponse, nil
+}
+
+func (c *Client) CreateIndex(name string, tableName string, fieldName string) (response.CreateIndexResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.CreateIndexMethod
+	r["name"] = name
+	r["tableName"] = tableName
+	r["fieldName"] = fieldName
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.CreateIndexResponse{}, err
+	}
+
+	var createIndexResponse response.CreateIndexResponse
+
+	err = mapToStruct(res, &createIndexResponse)
+
+	if err != nil {
+		return response.CreateIndexResponse{}, err
+	}
+
+	return createIndexResponse, nil
+}
+
+func (c *Client) DropIndex(name string, tableName string, fieldName string) (response.DropIndexResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.DropIndexMethod
+	r["name"] = name
+	r["tableName"] = tableName
+	r["fieldName"] = fieldName
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.DropIndexResponse{}, err
+	}
+
+	var dropIndexResponse response.DropIndexResponse
+
+	err = mapToStruct(res, &dropIndexResponse)
+
+	if err != nil {
+		return response.DropIndexResponse{}, err
+	}
+
+	return dropIndexResponse, nil
+}
+
 func (c *Client) SubscribeToMetricUpdates() (response.SubscribeToMetricUpdatesResponse, error) {
 	r := make(map[string]interface{})
 
@@ -352,3 +650,145 @@ func (c *Client) UnsubscribeFromMetricUpdates() (response.UnsubscribedFromMetric
 
 	return unsubscribeFromMetricUpdatesResponse, nil
 }
+
+func (c *Client) BeginTransaction(name string, tableName string) (response.BeginTransactionResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.BeginTransactionMethod
+	r["name"] = name
+	r["tableName"] = tableName
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.BeginTransactionResponse{}, err
+	}
+
+	var beginTransactionResponse response.BeginTransactionResponse
+
+	err = mapToStruct(res, &beginTransactionResponse)
+
+	if err != nil {
+		return response.BeginTransactionResponse{}, err
+	}
+
+	return beginTransactionResponse, nil
+}
+
+func (c *Client) Commit(transactionId string) (response.CommitTransactionResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.CommitTransactionMethod
+	r["transactionId"] = transactionId
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.CommitTransactionResponse{}, err
+	}
+
+	var commitTransactionResponse response.CommitTransactionResponse
+
+	err = mapToStruct(res, &commitTransactionResponse)
+
+	if err != nil {
+		return response.CommitTransactionResponse{}, err
+	}
+
+	return commitTransactionResponse, nil
+}
+
+func (c *Client) Rollback(transactionId string) (response.RollbackTransactionResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.RollbackTransactionMethod
+	r["transactionId"] = transactionId
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.RollbackTransactionResponse{}, err
+	}
+
+	var rollbackTransactionResponse response.RollbackTransactionResponse
+
+	err = mapToStruct(res, &rollbackTransactionResponse)
+
+	if err != nil {
+		return response.RollbackTransactionResponse{}, err
+	}
+
+	return rollbackTransactionResponse, nil
+}
+
+func (c *Client) InsertToDatabaseTableInTransaction(transactionId string, object map[string]json.RawMessage) (response.InsertToDatabaseTableResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.InsertToDatabaseTableMethod
+	r["transactionId"] = transactionId
+	r["object"] = object
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.InsertToDatabaseTableResponse{}, err
+	}
+
+	var insertToDatabaseTableResponse response.InsertToDatabaseTableResponse
+
+	err = mapToStruct(res, &insertToDatabaseTableResponse)
+
+	if err != nil {
+		return response.InsertToDatabaseTableResponse{}, err
+	}
+
+	return insertToDatabaseTableResponse, nil
+}
+
+func (c *Client) RemoveFromDatabaseTableInTransaction(transactionId string, request request.Request) (response.RemoveFromDatabaseTableResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.RemoveFromDatabaseTableMethod
+	r["transactionId"] = transactionId
+	r["request"] = request
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.RemoveFromDatabaseTableResponse{}, err
+	}
+
+	var removeFromDatabaseTableResponse response.RemoveFromDatabaseTableResponse
+
+	err = mapToStruct(res, &removeFromDatabaseTableResponse)
+
+	if err != nil {
+		return response.RemoveFromDatabaseTableResponse{}, err
+	}
+
+	return removeFromDatabaseTableResponse, nil
+}
+
+func (c *Client) UpdateInDatabaseTableInTransaction(transactionId string, object map[string]interface{}) (response.UpdateInDatabaseTableResponse, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.UpdateInDatabaseTableMethod
+	r["transactionId"] = transactionId
+	r["object"] = object
+
+	res, err := c.sendRequest(r)
+
+	if err != nil {
+		return response.UpdateInDatabaseTableResponse{}, err
+	}
+
+	var updateInDatabaseTableResponse response.UpdateInDatabaseTableResponse
+
+	err = mapToStruct(res, &updateInDatabaseTableResponse)
+
+	if err != nil {
+		return response.UpdateInDatabaseTableResponse{}, err
+	}
+
+	return updateInDatabaseTableResponse, nil
+}
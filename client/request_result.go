@@ -23,10 +23,16 @@ func (c *Client) handleRequestResultResponseMethod(msg map[string]interface{}) {
 			r.Err = errors.New(msg["message"].(string))
 		}
 
-		if c.getChannel(requestId) != nil {
-			c.getChannel(requestId) <- r
-		} else if c.connected {
-			panic(r.Err)
+		//responses of requests that timed out or were closed are dropped
+		channel := c.getChannel(requestId)
+
+		if channel == nil {
+			return
+		}
+
+		select {
+		case channel.results <- r:
+		case <-channel.removed:
 		}
 	}
 }
@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/models/method"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+	"github.com/lucasl0st/InfiniteDB/models/response"
+	"time"
+)
+
+// ResultIterator reads streamed results one chunk at a time
+type ResultIterator struct {
+	c         *Client
+	requestId int64
+
+	results []map[string]json.RawMessage
+	object  map[string]json.RawMessage
+	end     *response.GetFromDatabaseTableChunkResponse
+	err     error
+	closed  bool
+}
+
+// StreamFromDatabaseTable requests the results in chunks of chunkSize objects instead of one response, 0 uses the default of the server
+func (c *Client) StreamFromDatabaseTable(name string, tableName string, request request.Request, chunkSize int64) (*ResultIterator, error) {
+	r := make(map[string]interface{})
+
+	r["method"] = method.GetFromDatabaseTableMethod
+	r["name"] = name
+	r["tableName"] = tableName
+	r["request"] = request
+	r["stream"] = true
+
+	if chunkSize > 0 {
+		r["chunkSize"] = chunkSize
+	}
+
+	requestId, err := c.send(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResultIterator{
+		c:         c,
+		requestId: requestId,
+	}, nil
+}
+
+// Next moves to the next object, it returns false after the last object or if reading failed
+func (i *ResultIterator) Next() bool {
+	for len(i.results) == 0 {
+		if i.end != nil || i.err != nil || i.closed {
+			return false
+		}
+
+		res, err := i.c.getResponse(i.requestId)
+
+		if err != nil {
+			i.err = err
+			i.drain()
+			return false
+		}
+
+		var chunk response.GetFromDatabaseTableChunkResponse
+
+		err = mapToStruct(res, &chunk)
+
+		if err != nil {
+			i.err = err
+			i.drain()
+			return false
+		}
+
+		if chunk.Done {
+			i.end = &chunk
+			i.c.removeChannel(i.requestId)
+		}
+
+		i.results = chunk.Results
+	}
+
+	i.object = i.results[0]
+	i.results = i.results[1:]
+
+	return true
+}
+
+// Object returns the object Next moved to
+func (i *ResultIterator) Object() map[string]json.RawMessage {
+	return i.object
+}
+
+func (i *ResultIterator) Err() error {
+	return i.err
+}
+
+// End returns the final chunk with the count, next cursor and explain, it is nil until all objects were read
+func (i *ResultIterator) End() *response.GetFromDatabaseTableChunkResponse {
+	return i.end
+}
+
+// Close stops the iterator, chunks that are still sent by the server are discarded
+func (i *ResultIterator) Close() {
+	if i.end != nil || i.err != nil || i.closed {
+		return
+	}
+
+	i.closed = true
+	i.results = nil
+	i.drain()
+}
+
+// drain discards the remaining chunks in the background until the last one, a timeout or an error,
+// the channel is removed afterwards so late chunks are dropped by the read loop
+func (i *ResultIterator) drain() {
+	channel := i.c.getChannel(i.requestId)
+
+	if channel == nil {
+		return
+	}
+
+	go func() {
+		defer i.c.removeChannel(i.requestId)
+
+		for {
+			select {
+			case res := <-channel.results:
+				if res.Err != nil {
+					return
+				}
+
+				if done, _ := res.M["done"].(bool); done {
+					return
+				}
+			case <-time.After(i.c.timeout):
+				return
+			}
+		}
+	}()
+}
@@ -54,6 +54,15 @@ func (c *Cache) Remove(id int64) {
 	c.m.Delete(id)
 }
 
+func (c *Cache) Clear() {
+	c.m.Range(func(key, value any) bool {
+		c.m.Delete(key)
+		return true
+	})
+
+	c.count = 0
+}
+
 func (c *Cache) Get(id int64) *map[string]dbtype.DBType {
 	a, have := c.m.Load(id)
 
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/table"
+	"strings"
+)
+
+func (d *Database) aggregate(t *table.Table, request table.Request, explain *table.ExplainStage) ([]map[string]json.RawMessage, error) {
+	s := explain.Add(table.NewExplainStage(table.QueryStage))
+
+	q, err := d.joinQuery(t, *request.Query, request.Implement, s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	objects, _, err := t.Query(q, nil, make(table.AdditionalFields), s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.Finish(t.Size(), len(objects))
+
+	//inner and anti implements decide which objects are aggregated, like they decide which objects are returned
+	objects, err = d.filterImplemented(t, request.Implement, objects, explain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s = explain.Add(table.NewExplainStage(table.AggregateStage))
+
+	rows, err := t.Aggregate(objects, *request.Aggregate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.Finish(len(objects), len(rows))
+
+	if len(request.Sort) > 0 {
+		s = table.NewExplainStage(table.SortStage)
+		s.Field = strings.Join(request.Sort.Fields(), ", ")
+		s = explain.Add(s)
+
+		rows, err = table.SortRows(rows, request.Sort)
+
+		if err != nil {
+			return nil, err
+		}
+
+		s.Finish(len(rows), len(rows))
+	}
+
+	if request.Skip != nil || request.Limit != nil {
+		s = explain.Add(table.NewExplainStage(table.SkipAndLimitStage))
+
+		before := len(rows)
+
+		rows = table.SkipAndLimitRows(rows, request.Skip, request.Limit)
+
+		s.Finish(before, len(rows))
+	}
+
+	p := newProjection(request.Fields, request.Exclude)
+
+	results := make([]map[string]json.RawMessage, 0, len(rows))
+
+	for _, row := range rows {
+		m := map[string]json.RawMessage{}
+
+		for name, value := range row {
+			if p.includes(name) {
+				m[name] = value.ToJsonRaw()
+			}
+		}
+
+		results = append(results, m)
+	}
+
+	return results, nil
+}
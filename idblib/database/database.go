@@ -17,24 +17,30 @@ import (
 	"github.com/lucasl0st/InfiniteDB/models/request"
 	"github.com/lucasl0st/InfiniteDB/util"
 	"os"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
 )
 
+const tableConfigFileName = "table.json"
+
 type Database struct {
 	Name              string
 	path              string
 	tablesPath        string
 	tables            map[string]*table.Table
+	tablesLock        sync.RWMutex
 	l                 idbutil.Logger
 	m                 *metrics.Metrics
 	cacheSize         uint
+	scanLimit         uint
 	watchForNewTables bool
 	watcher           *fsnotify.Watcher
+	reloadLock        sync.Mutex
 }
 
-func NewDatabase(name string, path string, logger idbutil.Logger, metrics *metrics.Metrics, cacheSize uint) (*Database, int, error) {
+func NewDatabase(name string, path string, logger idbutil.Logger, metrics *metrics.Metrics, cacheSize uint, scanLimit uint) (*Database, int, error) {
 	watcher, err := fsnotify.NewWatcher()
 
 	if err != nil {
@@ -57,6 +63,7 @@ func NewDatabase(name string, path string, logger idbutil.Logger, metrics *metri
 		l:                 logger,
 		m:                 metrics,
 		cacheSize:         cacheSize,
+		scanLimit:         scanLimit,
 		watchForNewTables: true,
 		watcher:           watcher,
 	}
@@ -73,7 +80,7 @@ func NewDatabase(name string, path string, logger idbutil.Logger, metrics *metri
 		}
 	}()
 
-	return database, len(database.tables), err
+	return database, len(database.GetTableNames()), err
 }
 
 func CreateDatabase(path string, name string) error {
@@ -103,6 +110,17 @@ func (d *Database) tablesWatcher() {
 		isTable := !strings.Contains(tableName, "/")
 
 		if !isTable {
+			//the config of a table was changed, possibly by another process
+			parts := strings.Split(tableName, "/")
+
+			if len(parts) == 2 && parts[1] == tableConfigFileName && (event.Has(fsnotify.Create) || event.Has(fsnotify.Write)) {
+				err := d.reloadTable(parts[0])
+
+				if err != nil {
+					d.l.Println("failed to reload table " + parts[0] + ": " + err.Error())
+				}
+			}
+
 			return
 		}
 
@@ -115,14 +133,13 @@ func (d *Database) tablesWatcher() {
 				d.l.Fatal(err.Error())
 			}
 		} else if event.Has(fsnotify.Remove) {
-			t := d.tables[tableName]
+			t := d.removeTable(tableName)
 
 			if t == nil {
 				return
 			}
 
-			t.Storage.Kill()
-			delete(d.tables, tableName)
+			t.Kill()
 		}
 	}
 }
@@ -164,42 +181,146 @@ func (d *Database) loadTables() error {
 }
 
 func (d *Database) loadTable(name string) error {
+	if d.getTable(name) != nil {
+		return nil
+	}
+
+	t, err := d.openTable(name)
+
+	if err != nil {
+		return err
+	}
+
+	d.tablesLock.Lock()
+
+	//loaded by another goroutine in the meantime
 	if d.tables[name] != nil {
+		d.tablesLock.Unlock()
+		t.Kill()
 		return nil
 	}
 
+	d.tables[name] = t
+
+	d.tablesLock.Unlock()
+
+	return nil
+}
+
+func (d *Database) openTable(name string) (*table.Table, error) {
 	start := time.Now()
 
-	bytes, err := os.ReadFile(d.tablesPath + name + "/table.json")
+	config, err := d.readTableConfig(name)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var fields field.TableConfig
-	err = json.Unmarshal(bytes, &fields)
+	err = d.watcher.Add(d.tablesPath + name)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	t, err := table.NewTable(d.Name, name, d.tablesPath, fields, d.l, d.m, d.cacheSize)
+	t, err := table.NewTable(d.Name, name, d.tablesPath, config, d.l, d.m, d.cacheSize, d.scanLimit)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	d.tables[name] = t
-
 	elapsed := time.Since(start)
 
 	d.l.Println("loaded table "+name+" with "+fmt.Sprint(t.Storage.NumberOfObjects)+" objects, took ", elapsed)
 
+	return t, nil
+}
+
+func (d *Database) getTable(name string) *table.Table {
+	d.tablesLock.RLock()
+	defer d.tablesLock.RUnlock()
+
+	return d.tables[name]
+}
+
+func (d *Database) getTables() []*table.Table {
+	d.tablesLock.RLock()
+	defer d.tablesLock.RUnlock()
+
+	tables := make([]*table.Table, 0, len(d.tables))
+
+	for _, t := range d.tables {
+		tables = append(tables, t)
+	}
+
+	return tables
+}
+
+// removeTable removes the table from the database and returns it, the caller has to kill or delete it
+func (d *Database) removeTable(name string) *table.Table {
+	d.tablesLock.Lock()
+	defer d.tablesLock.Unlock()
+
+	t := d.tables[name]
+	delete(d.tables, name)
+
+	return t
+}
+
+func (d *Database) readTableConfig(name string) (field.TableConfig, error) {
+	bytes, err := os.ReadFile(d.tablesPath + name + "/" + tableConfigFileName)
+
+	if err != nil {
+		return field.TableConfig{}, err
+	}
+
+	var config field.TableConfig
+	err = json.Unmarshal(bytes, &config)
+
+	if err != nil {
+		return field.TableConfig{}, err
+	}
+
+	return config, nil
+}
+
+func (d *Database) reloadTable(name string) error {
+	d.reloadLock.Lock()
+	defer d.reloadLock.Unlock()
+
+	t := d.getTable(name)
+
+	if t == nil {
+		return nil
+	}
+
+	config, err := d.readTableConfig(name)
+
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(config, t.StoredConfig()) {
+		return nil
+	}
+
+	reloaded, err := d.openTable(name)
+
+	if err != nil {
+		return err
+	}
+
+	//swapped before the old table is killed, requests still using it fail instead of writing with the old config
+	d.tablesLock.Lock()
+	d.tables[name] = reloaded
+	d.tablesLock.Unlock()
+
+	t.Kill()
+
 	return nil
 }
 
 func (d *Database) CreateTable(name string, fields map[string]field.Field, options request.TableOptions) error {
-	if d.tables[name] != nil {
+	if d.getTable(name) != nil {
 		return e.TableAlreadyExists()
 	}
 
@@ -220,7 +341,7 @@ func (d *Database) CreateTable(name string, fields map[string]field.Field, optio
 		return err
 	}
 
-	err = os.WriteFile(d.tablesPath+name+"/table.json", bytes, 0644)
+	err = os.WriteFile(d.tablesPath+name+"/"+tableConfigFileName, bytes, 0644)
 
 	if err != nil {
 		return err
@@ -232,7 +353,7 @@ func (d *Database) CreateTable(name string, fields map[string]field.Field, optio
 func (d *Database) GetTableNames() []string {
 	var tableNames []string
 
-	for _, t := range d.tables {
+	for _, t := range d.getTables() {
 		tableNames = append(tableNames, t.Name)
 	}
 
@@ -240,7 +361,7 @@ func (d *Database) GetTableNames() []string {
 }
 
 func (d *Database) GetTable(tableName string) (map[string]request.Field, *request.TableOptions, error) {
-	t := d.tables[tableName]
+	t := d.getTable(tableName)
 
 	if t == nil {
 		return nil, nil, e.TableDoesNotExist()
@@ -249,103 +370,318 @@ func (d *Database) GetTable(tableName string) (map[string]request.Field, *reques
 	fields := map[string]request.Field{}
 
 	for name, f := range t.Config.Fields {
+		var defaultValue json.RawMessage
+
+		if f.Default != nil {
+			v, err := idbutil.StringToDBType(*f.Default, f)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			defaultValue = v.ToJsonRaw()
+		}
+
 		fields[name] = request.Field{
 			Type:    fmt.Sprint(f.Type),
 			Indexed: util.Ptr(f.Indexed),
 			Unique:  util.Ptr(f.Unique),
 			Null:    util.Ptr(f.Null),
+			Default: defaultValue,
 		}
 	}
 
 	return fields, &t.Config.Options, nil
 }
 
-func (d *Database) Get(tableName string, request table.Request) ([]map[string]json.RawMessage, error) {
-	if request.Query != nil {
-		t := d.tables[tableName]
+// query returns the objects of request in order and the cursor to continue after them if request.Cursor is set
+func (d *Database) query(t *table.Table, request table.Request, explain *table.ExplainStage) (object.Objects, table.AdditionalFields, *table.Cursor, error) {
+	s := explain.Add(table.NewExplainStage(table.QueryStage))
+
+	q, err := d.joinQuery(t, *request.Query, request.Implement, s)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	objects, additionalFields, err := t.Query(q, nil, make(table.AdditionalFields), s)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	s.Finish(t.Size(), len(objects))
+
+	objects, err = d.filterImplemented(t, request.Implement, objects, explain)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if request.Cursor != nil {
+		s = table.NewExplainStage(table.PageStage)
+		s.Field = strings.Join(request.Cursor.Keys.Fields(), ", ")
+		s = explain.Add(s)
+
+		before := len(objects)
+
+		sortFields, err := d.joinSortFields(t, request.Implement, request.Cursor.Keys, objects, additionalFields)
 
-		if t == nil {
-			return nil, e.TableDoesNotExist()
+		if err != nil {
+			return nil, nil, nil, err
 		}
 
-		objects, additionalFields, err := t.Query(*request.Query, nil, make(table.AdditionalFields))
+		objects, next, err := t.Page(objects, *request.Cursor, sortFields, request.Limit)
 
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
+		}
+
+		s.Finish(before, len(objects))
+
+		return objects, additionalFields, next, nil
+	}
+
+	if len(request.Sort) > 0 {
+		s = table.NewExplainStage(table.SortStage)
+		s.Field = strings.Join(request.Sort.Fields(), ", ")
+		s = explain.Add(s)
+
+		before := len(objects)
+
+		//only the objects up to the limit have to be ordered
+		var k *int64
+
+		if request.Limit != nil {
+			k = util.Ptr(*request.Limit)
+
+			if request.Skip != nil {
+				*k += *request.Skip
+			}
 		}
 
-		if request.Sort != nil {
-			objects, err = t.Sort(objects, request.Sort.Field, additionalFields, request.Sort.Direction)
+		sortFields, err := d.joinSortFields(t, request.Implement, request.Sort, objects, additionalFields)
+
+		if err != nil {
+			return nil, nil, nil, err
 		}
 
+		objects, err = t.Sort(objects, request.Sort, sortFields, k)
+
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
 
+		s.Finish(before, len(objects))
+	}
+
+	if request.Skip != nil || request.Limit != nil {
+		s = explain.Add(table.NewExplainStage(table.SkipAndLimitStage))
+
+		before := len(objects)
+
 		objects = t.SkipAndLimit(objects, request.Skip, request.Limit)
 
-		results := t.Storage.GetObjects(objects)
+		s.Finish(before, len(objects))
+	}
 
-		implementObjectsMap := map[int64]map[string]json.RawMessage{}
+	return objects, additionalFields, nil, nil
+}
 
-		if request.Implement != nil {
-			for _, id := range objects {
-				implementObjectsMap[id] = map[string]json.RawMessage{}
-			}
+// Get returns the objects matching request, the cursor of the next page if request.Cursor is set
+// and how they were found if request.Explain is set
+func (d *Database) Get(tableName string, request table.Request) ([]map[string]json.RawMessage, *table.Cursor, *table.ExplainStage, error) {
+	var results []map[string]json.RawMessage
 
-			for _, implement := range request.Implement {
-				i, as, err := d.implement(implement, results)
+	stream, err := d.Stream(tableName, request, 0)
 
-				if err != nil {
-					return nil, err
-				}
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
-				for id, implementedObject := range i {
-					implementObjectsMap[id][*as] = implementedObject
-				}
-			}
+	for {
+		objects, ok, err := stream.Next()
+
+		if err != nil {
+			return nil, nil, nil, err
 		}
 
-		interfaceObjects, err := d.objectsToMapStringJsonRawArray(results, t, implementObjectsMap, additionalFields)
+		if !ok {
+			break
+		}
+
+		results = append(results, objects...)
+	}
+
+	return results, stream.Cursor(), stream.Explain(), nil
+}
+
+// read reads the objects, implements the other tables into them and applies the projection
+func (d *Database) read(
+	t *table.Table,
+	request table.Request,
+	objects object.Objects,
+	additionalFields table.AdditionalFields,
+	p projection,
+	explain *table.ExplainStage,
+) ([]map[string]json.RawMessage, error) {
+	//implements need the value of their field even if it is not returned
+	fieldNames := p.tableFields(t)
+
+	for _, implement := range request.Implement {
+		fieldNames = append(fieldNames, implement.KeyFields()...)
+	}
+
+	s := table.NewExplainStage(table.ReadStage)
+
+	results, indexed := t.IndexedObjects(objects, fieldNames)
+
+	if indexed {
+		s.Access = table.IndexReadAccess
+	} else {
+		s.Access = table.StorageReadAccess
+
+		var err error
+
+		results, err = t.Storage.GetObjects(objects)
 
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		return interfaceObjects, err
-	} else {
-		return nil, nil
+	explain.Add(s).Finish(len(objects), len(results))
+
+	_, implementObjectsMap, err := d.implementAll(request.Implement, results, explain, true)
+
+	if err != nil {
+		return nil, err
 	}
+
+	return d.objectsToMapStringJsonRawArray(results, t, implementObjectsMap, additionalFields, p)
 }
 
-func (d *Database) Remove(tableName string, request table.Request) (int64, error) {
-	objects, err := d.Get(tableName, request)
+// Count returns the number of objects matching request after skip and limit, without reading them
+func (d *Database) Count(tableName string, request table.Request) (int64, error) {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return 0, e.TableDoesNotExist()
+	}
+
+	if request.Query == nil {
+		return 0, nil
+	}
+
+	q, err := d.joinQuery(t, *request.Query, request.Implement, nil)
 
 	if err != nil {
 		return 0, err
 	}
 
-	t := d.tables[tableName]
+	objects, additionalFields, err := t.Query(q, nil, make(table.AdditionalFields), nil)
 
-	var count int64 = 0
+	if err != nil {
+		return 0, err
+	}
 
-	for _, o := range objects {
-		id, err := t.FindExisting(o)
+	objects, err = d.filterImplemented(t, request.Implement, objects, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if request.Cursor != nil {
+		additionalFields, err = d.joinSortFields(t, request.Implement, request.Cursor.Keys, objects, additionalFields)
 
 		if err != nil {
-			return count, err
+			return 0, err
 		}
 
-		om, err := t.JsonRawMapToMapDbType(o)
+		objects, _, err = t.Page(objects, *request.Cursor, additionalFields, request.Limit)
+
+		return int64(len(objects)), err
+	}
+
+	//the order does not change the count, sorting is not needed
+	return int64(len(t.SkipAndLimit(objects, request.Skip, request.Limit))), nil
+}
+
+func (d *Database) Exists(tableName string, request table.Request) (bool, error) {
+	count, err := d.Count(tableName, request)
+
+	return count > 0, err
+}
+
+func (d *Database) Remove(tableName string, request table.Request) (int64, error) {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return 0, e.TableDoesNotExist()
+	}
+
+	if request.Query == nil {
+		return 0, nil
+	}
+
+	objects, _, _, err := d.query(t, request, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	found, err := t.Storage.GetObjects(objects)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64 = 0
+
+	for _, o := range found {
+		err = t.Remove(&o)
 
 		if err != nil {
 			return count, err
 		}
 
-		err = t.Remove(&object.Object{
-			Id: id,
-			M:  om,
-		})
+		count++
+	}
+
+	return count, nil
+}
+
+func (d *Database) Begin(tableName string) (*table.Transaction, error) {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return nil, e.TableDoesNotExist()
+	}
+
+	return t.Begin(), nil
+}
+
+func (d *Database) RemoveInTransaction(tx *table.Transaction, request table.Request) (int64, error) {
+	if request.Query == nil {
+		return 0, nil
+	}
+
+	objects, _, _, err := d.query(tx.Table, request, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	found, err := tx.Table.Storage.GetObjects(objects)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64 = 0
+
+	for _, o := range found {
+		err = tx.Remove(&o)
 
 		if err != nil {
 			return count, err
@@ -358,7 +694,7 @@ func (d *Database) Remove(tableName string, request table.Request) (int64, error
 }
 
 func (d *Database) Insert(tableName string, o map[string]json.RawMessage) error {
-	t := d.tables[tableName]
+	t := d.getTable(tableName)
 
 	if t == nil {
 		return e.TableDoesNotExist()
@@ -367,8 +703,18 @@ func (d *Database) Insert(tableName string, o map[string]json.RawMessage) error
 	return t.Insert(o)
 }
 
+func (d *Database) BulkInsert(tableName string, objects []map[string]json.RawMessage) ([]error, error) {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return nil, e.TableDoesNotExist()
+	}
+
+	return t.BulkInsert(objects)
+}
+
 func (d *Database) Update(tableName string, o map[string]json.RawMessage) error {
-	t := d.tables[tableName]
+	t := d.getTable(tableName)
 
 	if t == nil {
 		return e.TableDoesNotExist()
@@ -377,11 +723,94 @@ func (d *Database) Update(tableName string, o map[string]json.RawMessage) error
 	return t.Update(o)
 }
 
+// Upsert updates the object with the same unique values as o or inserts o, it returns true if o was inserted
+func (d *Database) Upsert(tableName string, o map[string]json.RawMessage) (bool, error) {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return false, e.TableDoesNotExist()
+	}
+
+	return t.Upsert(o)
+}
+
+// UpdateWhere applies updates to the objects matching q, it returns the number of matching objects and the number of changed objects
+func (d *Database) UpdateWhere(tableName string, q table.Query, updates []table.FieldUpdate) (int64, int64, error) {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return 0, 0, e.TableDoesNotExist()
+	}
+
+	return t.UpdateWhere(func() (object.Objects, error) {
+		objects, _, _, err := d.query(t, table.Request{Query: &q}, nil)
+
+		return objects, err
+	}, updates)
+}
+
+func (d *Database) Compact(tableName string) (int64, int64, error) {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return 0, 0, e.TableDoesNotExist()
+	}
+
+	return t.Compact()
+}
+
+func (d *Database) AlterTable(tableName string, changes []table.AlterTableChange) error {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return e.TableDoesNotExist()
+	}
+
+	_, err := t.Alter(changes)
+
+	if err != nil {
+		return err
+	}
+
+	return d.reloadTable(tableName)
+}
+
+func (d *Database) CreateIndex(tableName string, fieldName string) (table.IndexBuildProgress, error) {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return table.IndexBuildProgress{}, e.TableDoesNotExist()
+	}
+
+	return t.CreateIndex(fieldName)
+}
+
+func (d *Database) DropIndex(tableName string, fieldName string) error {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return e.TableDoesNotExist()
+	}
+
+	return t.DropIndex(fieldName)
+}
+
+func (d *Database) IndexBuilds(tableName string) (map[string]table.IndexBuildProgress, error) {
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return nil, e.TableDoesNotExist()
+	}
+
+	return t.IndexBuilds(), nil
+}
+
 func (d *Database) objectsToMapStringJsonRawArray(
 	objects []object.Object,
 	t *table.Table,
 	implementObjectsMap map[int64]map[string]json.RawMessage,
 	additionalFields table.AdditionalFields,
+	p projection,
 ) ([]map[string]json.RawMessage, error) {
 	var results []map[string]json.RawMessage
 
@@ -392,11 +821,19 @@ func (d *Database) objectsToMapStringJsonRawArray(
 			return nil, err
 		}
 
+		for key := range interfaceMap {
+			if !p.includes(key) {
+				delete(interfaceMap, key)
+			}
+		}
+
 		implementObjects, ok := implementObjectsMap[o.Id]
 
 		if ok {
 			for key, value := range implementObjects {
-				interfaceMap[key] = value
+				if p.includes(key) {
+					interfaceMap[key] = value
+				}
 			}
 		}
 
@@ -404,7 +841,9 @@ func (d *Database) objectsToMapStringJsonRawArray(
 
 		if ok {
 			for key, value := range additional {
-				interfaceMap[key] = value.ToJsonRaw()
+				if p.includes(key) {
+					interfaceMap[key] = value.ToJsonRaw()
+				}
 			}
 		}
 
@@ -417,14 +856,14 @@ func (d *Database) objectsToMapStringJsonRawArray(
 func (d *Database) Kill() {
 	d.watchForNewTables = false
 
-	for _, t := range d.tables {
-		t.Storage.Kill()
+	for _, t := range d.getTables() {
+		t.Close()
 	}
 }
 
 func (d *Database) Delete() error {
-	for tableName, t := range d.tables {
-		delete(d.tables, tableName)
+	for _, t := range d.getTables() {
+		d.removeTable(t.Name)
 
 		err := t.Delete()
 
@@ -437,12 +876,11 @@ func (d *Database) Delete() error {
 }
 
 func (d *Database) DeleteTable(tableName string) error {
-	t := d.tables[tableName]
+	t := d.removeTable(tableName)
 
 	if t == nil {
 		return e.TableDoesNotExist()
 	}
 
-	delete(d.tables, tableName)
 	return t.Delete()
 }
@@ -6,86 +6,428 @@ package database
 
 import (
 	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
 	"github.com/lucasl0st/InfiniteDB/idblib/object"
 	"github.com/lucasl0st/InfiniteDB/idblib/table"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
 	e "github.com/lucasl0st/InfiniteDB/models/errors"
 	"github.com/lucasl0st/InfiniteDB/models/request"
+	"strings"
 )
 
-func (d *Database) implement(implement request.Implement, objects []object.Object) (map[int64]json.RawMessage, *string, error) {
-	fromTable := d.tables[implement.From.Table]
+// join holds the objects of another table matching a list of objects
+type join struct {
+	fromTable *table.Table
+
+	//objectId -> key of the values the implemented objects have to match
+	keys map[int64]string
+	//key -> indexes of the implemented objects in the order of the sort
+	matches map[string][]int
+
+	objects          []object.Object
+	additionalFields table.AdditionalFields
+	//objectId -> implemented objects of the nested implements
+	implemented map[int64]map[string]json.RawMessage
+}
+
+func (j *join) matched(id int64) bool {
+	key, ok := j.keys[id]
+
+	return ok && len(j.matches[key]) > 0
+}
+
+// implementAll runs the implements on objects, returns the objects that are kept by inner and anti implements
+// and if render is set the implemented objects of every object
+func (d *Database) implementAll(
+	implements []table.Implement,
+	objects []object.Object,
+	explain *table.ExplainStage,
+	render bool,
+) ([]object.Object, map[int64]map[string]json.RawMessage, error) {
+	implemented := map[int64]map[string]json.RawMessage{}
+
+	for _, implement := range implements {
+		//without rendering only the implements that filter the objects are needed
+		if !render && implement.Type != request.INNER && implement.Type != request.ANTI {
+			continue
+		}
+
+		s := table.NewExplainStage(table.ImplementStage)
+		s.Field = strings.Join(implement.KeyFields(), ", ")
+		s = explain.Add(s)
+
+		before := len(objects)
+
+		j, err := d.join(implement, objects, s, render)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if implement.Type == request.INNER || implement.Type == request.ANTI {
+			var kept []object.Object
+
+			for _, o := range objects {
+				if j.matched(o.Id) == (implement.Type == request.INNER) {
+					kept = append(kept, o)
+				}
+			}
+
+			objects = kept
+		}
+
+		after := len(objects)
+
+		if render && implement.Type != request.ANTI {
+			i, err := d.renderJoin(implement, j, objects)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			for id, implementedObject := range i {
+				if implemented[id] == nil {
+					implemented[id] = map[string]json.RawMessage{}
+				}
+
+				implemented[id][implement.As] = implementedObject
+			}
+
+			if implement.Type == request.LEFT {
+				after = len(i)
+			}
+		}
+
+		s.Finish(before, after)
+	}
+
+	return objects, implemented, nil
+}
+
+// filterImplemented removes the objects without a match for inner implements and with a match for anti implements
+func (d *Database) filterImplemented(t *table.Table, implements []table.Implement, objects object.Objects, explain *table.ExplainStage) (object.Objects, error) {
+	var fieldNames []string
+
+	for _, implement := range implements {
+		if implement.Type == request.INNER || implement.Type == request.ANTI {
+			fieldNames = append(fieldNames, implement.KeyFields()...)
+		}
+	}
+
+	if len(fieldNames) == 0 {
+		return objects, nil
+	}
+
+	keyObjects, err := readFields(t, objects, fieldNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	kept, _, err := d.implementAll(implements, keyObjects, explain, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make(object.Objects, 0, len(kept))
+
+	for _, o := range kept {
+		filtered = append(filtered, o.Id)
+	}
+
+	return filtered, nil
+}
+
+// join looks up the objects of the other table for all objects at once,
+// by probing the indexes of the key if it is unique or with a single query for all values otherwise
+func (d *Database) join(implement table.Implement, objects []object.Object, explain *table.ExplainStage, render bool) (*join, error) {
+	fromTable := d.getTable(implement.Table)
 
 	if fromTable == nil {
-		return nil, nil, e.TableDoesNotExist()
+		return nil, e.TableDoesNotExist()
 	}
 
-	implementObjectsMap := map[int64]json.RawMessage{}
+	var fromFields []field.Field
+	var fromFieldNames []string
+
+	for _, on := range implement.On {
+		f, ok := fromTable.Config.Fields[on.FromField]
+
+		if !ok {
+			return nil, e.CannotFindField(on.FromField)
+		}
+
+		fromFields = append(fromFields, f)
+		fromFieldNames = append(fromFieldNames, on.FromField)
+	}
+
+	j := &join{
+		fromTable: fromTable,
+		keys:      map[int64]string{},
+		matches:   map[string][]int{},
+	}
+
+	//every distinct combination of values is looked up once
+	seen := map[string]bool{}
+
+	var combinations [][]dbtype.DBType
 
 	for _, o := range objects {
-		i := o.M[implement.Field].ToJsonRaw()
+		values, err := implementValues(o, implement.KeyFields(), fromFields)
 
-		queryObjects, _, err := fromTable.Query(table.Query{
-			Where: &request.Where{
-				Field:    implement.From.Field,
-				Operator: request.EQUALS,
-				Value:    i,
-			},
-		}, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if values == nil {
+			continue
+		}
+
+		key := implementKey(values)
+		j.keys[o.Id] = key
+
+		if !seen[key] {
+			seen[key] = true
+			combinations = append(combinations, values)
+		}
+	}
+
+	if len(combinations) == 0 {
+		return j, nil
+	}
+
+	ids, additionalFields, err := d.lookup(fromTable, implement, fromFieldNames, combinations, explain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(implement.Sort) > 0 {
+		ids, err = fromTable.Sort(ids, implement.Sort, additionalFields, nil)
 
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
+	}
 
-		if len(queryObjects) > 0 {
-			forceArray := false
+	found, err := fromTable.Storage.GetObjects(ids)
 
-			if implement.ForceArray != nil && *implement.ForceArray {
-				forceArray = *implement.ForceArray
+	if err != nil {
+		return nil, err
+	}
+
+	fromObjects, implemented, err := d.implementAll(implement.Implement, found, explain, render)
+
+	if err != nil {
+		return nil, err
+	}
+
+	j.objects = fromObjects
+	j.additionalFields = additionalFields
+	j.implemented = implemented
+
+	for k, o := range fromObjects {
+		var values []dbtype.DBType
+
+		for _, name := range fromFieldNames {
+			v := o.M[name]
+
+			if v == nil || v.IsNull() {
+				values = nil
+				break
 			}
 
-			var i json.RawMessage
+			values = append(values, v)
+		}
 
-			var a []map[string]json.RawMessage
+		if values == nil {
+			continue
+		}
 
-			for _, id := range queryObjects {
-				o := fromTable.Storage.GetObject(id)
+		key := implementKey(values)
 
-				if o == nil {
-					continue
-				}
+		if implement.Limit != nil && int64(len(j.matches[key])) >= *implement.Limit {
+			continue
+		}
 
-				io, err := fromTable.ObjectToJsonRawMap(*o)
+		j.matches[key] = append(j.matches[key], k)
+	}
 
-				if err != nil {
-					return nil, nil, err
-				}
+	return j, nil
+}
+
+// lookup returns the objects of fromTable matching any of the combinations of values and the query of the implement
+func (d *Database) lookup(
+	fromTable *table.Table,
+	implement table.Implement,
+	fromFieldNames []string,
+	combinations [][]dbtype.DBType,
+	explain *table.ExplainStage,
+) (object.Objects, table.AdditionalFields, error) {
+	additionalFields := make(table.AdditionalFields)
+
+	if fromTable.IsUnique(fromFieldNames) {
+		ids, ok := probe(fromTable, fromFieldNames, combinations)
 
-				a = append(a, io)
+		if ok {
+			explain.SetAccess(table.IndexLookupAccess, strings.Join(fromFieldNames, ", "))
+
+			if implement.Query == nil {
+				return ids, additionalFields, nil
 			}
 
-			var b []byte
+			return fromTable.Query(*implement.Query, ids, additionalFields, explain)
+		}
+	}
+
+	q := table.Query{}
+
+	for k, name := range fromFieldNames {
+		var values []json.RawMessage
 
-			if len(a) > 1 || forceArray {
-				b, err = json.Marshal(a)
-			} else {
-				b, err = json.Marshal(a[0])
+		seen := map[string]bool{}
+
+		for _, combination := range combinations {
+			if !seen[combination[k].ToString()] {
+				seen[combination[k].ToString()] = true
+				values = append(values, combination[k].ToJsonRaw())
 			}
+		}
+
+		in, err := json.Marshal(values)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		q.And = append(q.And, table.Query{
+			Where: &request.Where{
+				Field:    name,
+				Operator: request.IN,
+				Value:    in,
+			},
+		})
+	}
+
+	if implement.Query != nil {
+		q.And = append(q.And, *implement.Query)
+	}
+
+	return fromTable.Query(q, nil, additionalFields, explain)
+}
+
+// probe looks up every combination in the indexes of the fields, it returns false if one of the fields is not indexed
+func probe(t *table.Table, fieldNames []string, combinations [][]dbtype.DBType) (object.Objects, bool) {
+	results := object.Objects{}
+
+	for _, values := range combinations {
+		var ids map[int64]bool
+
+		for k, name := range fieldNames {
+			i, err := t.GetIndex(name)
 
 			if err != nil {
-				return nil, nil, err
+				return nil, false
 			}
 
-			i = b
+			next := map[int64]bool{}
 
-			implementObjectsMap[o.Id] = i
+			for _, id := range i.Equal(values[k]) {
+				if ids == nil || ids[id] {
+					next[id] = true
+				}
+			}
+
+			ids = next
+
+			if len(ids) == 0 {
+				break
+			}
+		}
+
+		for id := range ids {
+			results = append(results, id)
+		}
+	}
+
+	return results, true
+}
+
+// renderJoin returns the implemented objects of every object that has a match
+func (d *Database) renderJoin(implement table.Implement, j *join, objects []object.Object) (map[int64]json.RawMessage, error) {
+	implementObjectsMap := map[int64]json.RawMessage{}
+
+	results, err := d.objectsToMapStringJsonRawArray(j.objects, j.fromTable, j.implemented, j.additionalFields, newProjection(implement.Fields, nil))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range objects {
+		if !j.matched(o.Id) {
+			continue
+		}
+
+		var a []map[string]json.RawMessage
+
+		for _, k := range j.matches[j.keys[o.Id]] {
+			a = append(a, results[k])
+		}
+
+		var b []byte
+
+		if len(a) > 1 || implement.ForceArray {
+			b, err = json.Marshal(a)
+		} else {
+			b, err = json.Marshal(a[0])
+		}
+
+		if err != nil {
+			return nil, err
 		}
+
+		implementObjectsMap[o.Id] = b
 	}
 
-	as := implement.From.Table
+	return implementObjectsMap, nil
+}
+
+// implementValues returns the values of the key fields of o converted to the types of the fields they are matched with,
+// nil if one of them is missing or null, a null key never matches like in sql
+func implementValues(o object.Object, fieldNames []string, fromFields []field.Field) ([]dbtype.DBType, error) {
+	var values []dbtype.DBType
+
+	for k, name := range fieldNames {
+		v := o.M[name]
+
+		if v == nil || v.IsNull() {
+			return nil, nil
+		}
+
+		value, err := idbutil.JsonRawToDBType(v.ToJsonRaw(), fromFields[k])
+
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func implementKey(values []dbtype.DBType) string {
+	var key []string
+
+	for _, value := range values {
+		if value == nil {
+			return ""
+		}
 
-	if implement.As != nil {
-		as = *implement.As
+		key = append(key, value.ToString())
 	}
 
-	return implementObjectsMap, &as, nil
+	return strings.Join(key, "\x00")
 }
@@ -0,0 +1,370 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	"github.com/lucasl0st/InfiniteDB/idblib/table"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+	"strings"
+)
+
+// joinedField returns the implement a field of an implemented table belongs to and the name of the field in that table,
+// fields of implemented tables are addressed by the as name of the implement and the field separated by a dot
+func joinedField(t *table.Table, implements []table.Implement, fieldName string) (table.Implement, string, bool) {
+	if _, ok := t.Config.Fields[fieldName]; ok {
+		return table.Implement{}, "", false
+	}
+
+	for _, implement := range implements {
+		if rest, ok := strings.CutPrefix(fieldName, implement.As+"."); ok && len(rest) > 0 {
+			return implement, rest, true
+		}
+	}
+
+	return table.Implement{}, "", false
+}
+
+// joinQuery replaces every where on a field of an implemented table with a where on the fields the implement matches,
+// the where itself is evaluated in the other table using its indexes
+func (d *Database) joinQuery(t *table.Table, q table.Query, implements []table.Implement, explain *table.ExplainStage) (table.Query, error) {
+	if len(implements) == 0 {
+		return q, nil
+	}
+
+	var err error
+
+	//the queries of the request are reused by cursors on the server, they are copied instead of changed
+	q.And, err = d.joinQueries(t, q.And, implements, explain)
+
+	if err != nil {
+		return q, err
+	}
+
+	q.Or, err = d.joinQueries(t, q.Or, implements, explain)
+
+	if err != nil {
+		return q, err
+	}
+
+	q.Not, err = d.joinQueries(t, q.Not, implements, explain)
+
+	if err != nil {
+		return q, err
+	}
+
+	if q.Where == nil {
+		return q, nil
+	}
+
+	implement, fieldName, ok := joinedField(t, implements, q.Where.Field)
+
+	if !ok {
+		return q, nil
+	}
+
+	s := table.NewExplainStage(table.ImplementStage)
+	s.Field = q.Where.Field
+	s = explain.Add(s)
+
+	joined, err := d.joinWhere(implement, fieldName, *q.Where, s)
+
+	if err != nil {
+		return q, err
+	}
+
+	q.Where = nil
+	q.And = append([]table.Query{joined}, q.And...)
+
+	return q, nil
+}
+
+func (d *Database) joinQueries(t *table.Table, queries []table.Query, implements []table.Implement, explain *table.ExplainStage) ([]table.Query, error) {
+	if queries == nil {
+		return nil, nil
+	}
+
+	results := make([]table.Query, 0, len(queries))
+
+	for _, q := range queries {
+		joined, err := d.joinQuery(t, q, implements, explain)
+
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, joined)
+	}
+
+	return results, nil
+}
+
+// joinWhere finds the objects of the other table matching w and returns a query for the objects implementing one of them
+func (d *Database) joinWhere(implement table.Implement, fieldName string, w request.Where, explain *table.ExplainStage) (table.Query, error) {
+	fromTable := d.getTable(implement.Table)
+
+	if fromTable == nil {
+		return table.Query{}, e.TableDoesNotExist()
+	}
+
+	w.Field = fieldName
+
+	q, err := d.joinQuery(fromTable, table.Query{Where: &w}, implement.Implement, explain)
+
+	if err != nil {
+		return table.Query{}, err
+	}
+
+	if implement.Query != nil {
+		q = table.Query{And: []table.Query{q, *implement.Query}}
+	}
+
+	ids, _, err := fromTable.Query(q, nil, make(table.AdditionalFields), explain)
+
+	if err != nil {
+		return table.Query{}, err
+	}
+
+	var fromFieldNames []string
+
+	for _, on := range implement.On {
+		fromFieldNames = append(fromFieldNames, on.FromField)
+	}
+
+	fromObjects, err := readFields(fromTable, ids, fromFieldNames)
+
+	if err != nil {
+		return table.Query{}, err
+	}
+
+	explain.Finish(fromTable.Size(), len(fromObjects))
+
+	//every distinct combination of values becomes one alternative, a single field is matched with in instead
+	seen := map[string]bool{}
+
+	var combinations [][]json.RawMessage
+
+	for _, o := range fromObjects {
+		var values []dbtype.DBType
+		var raw []json.RawMessage
+
+		for _, name := range fromFieldNames {
+			v := o.M[name]
+
+			if v == nil || v.IsNull() {
+				values = nil
+				break
+			}
+
+			values = append(values, v)
+			raw = append(raw, v.ToJsonRaw())
+		}
+
+		key := implementKey(values)
+
+		if values == nil || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		combinations = append(combinations, raw)
+	}
+
+	if len(implement.On) == 1 {
+		var values []json.RawMessage
+
+		for _, combination := range combinations {
+			values = append(values, combination[0])
+		}
+
+		return inQuery(implement.On[0].Field, values)
+	}
+
+	if len(combinations) == 0 {
+		return inQuery(implement.On[0].Field, nil)
+	}
+
+	var alternatives []table.Query
+
+	for _, combination := range combinations {
+		var and []table.Query
+
+		for k, on := range implement.On {
+			and = append(and, table.Query{
+				Where: &request.Where{
+					Field:    on.Field,
+					Operator: request.EQUALS,
+					Value:    combination[k],
+				},
+			})
+		}
+
+		alternatives = append(alternatives, table.Query{And: and})
+	}
+
+	return table.Query{Or: alternatives}, nil
+}
+
+func inQuery(fieldName string, values []json.RawMessage) (table.Query, error) {
+	if values == nil {
+		values = []json.RawMessage{}
+	}
+
+	in, err := json.Marshal(values)
+
+	if err != nil {
+		return table.Query{}, err
+	}
+
+	return table.Query{
+		Where: &request.Where{
+			Field:    fieldName,
+			Operator: request.IN,
+			Value:    in,
+		},
+	}, nil
+}
+
+// joinSortFields returns additionalFields with the values of the sort keys on fields of implemented tables added,
+// an object takes the value of its first implemented object and null if it has none
+func (d *Database) joinSortFields(
+	t *table.Table,
+	implements []table.Implement,
+	keys request.SortKeys,
+	objects object.Objects,
+	additionalFields table.AdditionalFields,
+) (table.AdditionalFields, error) {
+	var joined []string
+
+	for _, key := range keys {
+		if _, _, ok := joinedField(t, implements, key.Field); ok {
+			joined = append(joined, key.Field)
+		}
+	}
+
+	if len(joined) == 0 {
+		return additionalFields, nil
+	}
+
+	var fieldNames []string
+
+	for _, implement := range implements {
+		fieldNames = append(fieldNames, implement.KeyFields()...)
+	}
+
+	keyObjects, err := readFields(t, objects, fieldNames)
+
+	if err != nil {
+		return nil, err
+	}
+
+	//the additional fields are returned with the objects, the sort values are only added to a copy
+	results := make(table.AdditionalFields, len(additionalFields))
+
+	for id, fields := range additionalFields {
+		results[id] = fields
+	}
+
+	for _, fieldName := range joined {
+		values, f, err := d.joinedValues(t, implements, fieldName, keyObjects)
+
+		if err != nil {
+			return nil, err
+		}
+
+		null, err := idbutil.NullDBType(f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range objects {
+			fields := make(map[string]dbtype.DBType, len(results[id])+1)
+
+			for name, value := range results[id] {
+				fields[name] = value
+			}
+
+			fields[fieldName] = null
+
+			if value, ok := values[id]; ok {
+				fields[fieldName] = value
+			}
+
+			results[id] = fields
+		}
+	}
+
+	return results, nil
+}
+
+// joinedValues returns the value of a field of an implemented table for every object that has an implemented object
+// and the field it belongs to
+func (d *Database) joinedValues(
+	t *table.Table,
+	implements []table.Implement,
+	fieldName string,
+	objects []object.Object,
+) (map[int64]dbtype.DBType, field.Field, error) {
+	implement, rest, _ := joinedField(t, implements, fieldName)
+
+	j, err := d.join(implement, objects, nil, false)
+
+	if err != nil {
+		return nil, field.Field{}, err
+	}
+
+	var nested map[int64]dbtype.DBType
+
+	f, ok := j.fromTable.Config.Fields[rest]
+
+	if !ok {
+		if _, _, ok = joinedField(j.fromTable, implement.Implement, rest); !ok {
+			return nil, field.Field{}, e.CannotFindField(fieldName)
+		}
+
+		nested, f, err = d.joinedValues(j.fromTable, implement.Implement, rest, j.objects)
+
+		if err != nil {
+			return nil, field.Field{}, err
+		}
+	}
+
+	values := map[int64]dbtype.DBType{}
+
+	for _, o := range objects {
+		if !j.matched(o.Id) {
+			continue
+		}
+
+		first := j.objects[j.matches[j.keys[o.Id]][0]]
+
+		if nested != nil {
+			if value, ok := nested[first.Id]; ok {
+				values[o.Id] = value
+			}
+		} else if value := first.M[rest]; value != nil {
+			values[o.Id] = value
+		}
+	}
+
+	return values, f, nil
+}
+
+// readFields reads the fields of the objects from the indexes if they are indexed and from the storage otherwise
+func readFields(t *table.Table, ids object.Objects, fieldNames []string) ([]object.Object, error) {
+	objects, indexed := t.IndexedObjects(ids, fieldNames)
+
+	if indexed {
+		return objects, nil
+	}
+
+	return t.Storage.GetObjects(ids)
+}
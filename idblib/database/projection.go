@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package database
+
+import (
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/table"
+)
+
+type projection struct {
+	fields  map[string]bool
+	exclude map[string]bool
+}
+
+func newProjection(fields []string, exclude []string) projection {
+	p := projection{}
+
+	if len(fields) > 0 {
+		p.fields = map[string]bool{}
+
+		for _, name := range fields {
+			p.fields[name] = true
+		}
+	}
+
+	if len(exclude) > 0 {
+		p.exclude = map[string]bool{}
+
+		for _, name := range exclude {
+			p.exclude[name] = true
+		}
+	}
+
+	return p
+}
+
+func (p projection) includes(name string) bool {
+	if p.fields != nil {
+		return p.fields[name]
+	}
+
+	return !p.exclude[name]
+}
+
+// tableFields returns the fields of t that are returned
+func (p projection) tableFields(t *table.Table) []string {
+	var fieldNames []string
+
+	for name := range t.Config.Fields {
+		if name != field.InternalObjectIdField && p.includes(name) {
+			fieldNames = append(fieldNames, name)
+		}
+	}
+
+	return fieldNames
+}
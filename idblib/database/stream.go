@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	"github.com/lucasl0st/InfiniteDB/idblib/table"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+)
+
+// Stream holds the result of a query whose objects are read in chunks, every call to Next reads one chunk
+// so the caller can send it before the next one is read
+type Stream struct {
+	d         *Database
+	t         *table.Table
+	request   table.Request
+	chunkSize int
+
+	objects          object.Objects
+	additionalFields table.AdditionalFields
+	p                projection
+
+	//aggregated rows are computed at once and only split into chunks
+	aggregated bool
+	rows       []map[string]json.RawMessage
+
+	start int
+	sent  int
+	done  bool
+
+	next    *table.Cursor
+	explain *table.ExplainStage
+}
+
+// Stream runs the query of request, Next reads the objects in chunks of chunkSize,
+// a chunkSize of 0 reads all objects in one chunk
+func (d *Database) Stream(tableName string, request table.Request, chunkSize int) (*Stream, error) {
+	if request.Query == nil {
+		return &Stream{done: true}, nil
+	}
+
+	t := d.getTable(tableName)
+
+	if t == nil {
+		return nil, e.TableDoesNotExist()
+	}
+
+	s := &Stream{
+		d:         d,
+		t:         t,
+		request:   request,
+		chunkSize: chunkSize,
+		p:         newProjection(request.Fields, request.Exclude),
+	}
+
+	if request.Explain {
+		s.explain = table.NewExplainStage(table.RequestStage)
+	}
+
+	if request.Aggregate != nil {
+		rows, err := d.aggregate(t, request, s.explain)
+
+		if err != nil {
+			return nil, err
+		}
+
+		s.explain.Finish(t.Size(), len(rows))
+
+		s.aggregated = true
+		s.rows = rows
+
+		return s, nil
+	}
+
+	objects, additionalFields, next, err := d.query(t, request, s.explain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.objects = objects
+	s.additionalFields = additionalFields
+	s.next = next
+
+	return s, nil
+}
+
+// Next reads the next chunk, it returns false once all chunks were read. An empty result is read as one empty chunk
+func (s *Stream) Next() ([]map[string]json.RawMessage, bool, error) {
+	if s.done {
+		return nil, false, nil
+	}
+
+	total := len(s.objects)
+
+	if s.aggregated {
+		total = len(s.rows)
+	}
+
+	end := total
+
+	if s.chunkSize > 0 && s.start+s.chunkSize < total {
+		end = s.start + s.chunkSize
+	}
+
+	var chunk []map[string]json.RawMessage
+
+	if s.aggregated {
+		chunk = s.rows[s.start:end]
+	} else {
+		var err error
+
+		chunk, err = s.d.read(s.t, s.request, s.objects[s.start:end], s.additionalFields, s.p, s.explain)
+
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	s.start = end
+	s.sent += len(chunk)
+
+	if end == total {
+		s.done = true
+
+		if !s.aggregated {
+			s.explain.Finish(s.t.Size(), s.sent)
+		}
+	}
+
+	return chunk, true, nil
+}
+
+// Cursor returns the cursor of the next page if the request had a cursor
+func (s *Stream) Cursor() *table.Cursor {
+	return s.next
+}
+
+// Explain returns how the objects were found if the request had explain set
+func (s *Stream) Explain() *table.ExplainStage {
+	return s.explain
+}
@@ -11,6 +11,7 @@ import (
 	"math"
 	"math/big"
 	"regexp"
+	"strings"
 )
 
 type Number struct {
@@ -44,6 +45,11 @@ func NumberFromFloat64(f float64) (Number, error) {
 	}, nil
 }
 
+// NumberFromRat converts r into a number rounded to scale decimal places
+func NumberFromRat(r *big.Rat, scale int) (Number, error) {
+	return NumberFromString(r.FloatString(scale))
+}
+
 func NumberFromNull() Number {
 	return Number{
 		n:    *big.NewFloat(0),
@@ -100,3 +106,53 @@ func (a Number) ToFloat64() float64 {
 
 	return f
 }
+
+// ToRat returns the exact decimal value of the number, nil if it is null
+func (a Number) ToRat() *big.Rat {
+	if a.null {
+		return nil
+	}
+
+	r, _ := new(big.Rat).SetString(a.ToString())
+
+	return r
+}
+
+// Add returns the exact sum of a and b, null values count as 0
+func (a Number) Add(b Number) (Number, error) {
+	sum := new(big.Rat).Add(a.rat(), b.rat())
+
+	scale := a.Scale()
+
+	if b.Scale() > scale {
+		scale = b.Scale()
+	}
+
+	return NumberFromRat(sum, scale)
+}
+
+// Mul returns the exact product of a and b, null values count as 0
+func (a Number) Mul(b Number) (Number, error) {
+	product := new(big.Rat).Mul(a.rat(), b.rat())
+
+	return NumberFromRat(product, a.Scale()+b.Scale())
+}
+
+func (a Number) rat() *big.Rat {
+	if a.null {
+		return new(big.Rat)
+	}
+
+	return a.ToRat()
+}
+
+// Scale returns the number of decimal places of the number
+func (a Number) Scale() int {
+	s := a.ToString()
+
+	if k := strings.IndexByte(s, '.'); k >= 0 {
+		return len(s) - k - 1
+	}
+
+	return 0
+}
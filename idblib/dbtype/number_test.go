@@ -5,6 +5,7 @@
 package dbtype
 
 import (
+	"math/big"
 	"testing"
 )
 
@@ -45,3 +46,98 @@ func TestNumberFromString(t *testing.T) {
 		}
 	}
 }
+
+func TestNumberRat(t *testing.T) {
+	cases := []struct {
+		a string
+		b string
+		o string
+	}{
+		{a: "0.1", b: "0.2", o: "0.3"},
+		{a: "1", b: "-1", o: "0"},
+		{a: "-9876543210.123456789", b: "0.000000001", o: "-9876543210.123456788"},
+		{a: "999999999999999999999999999999999999999999999999999999999999", b: "0.0000000000000000000000000000000000000001", o: "999999999999999999999999999999999999999999999999999999999999.0000000000000000000000000000000000000001"},
+	}
+
+	for _, tc := range cases {
+		a, err := NumberFromString(tc.a)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := NumberFromString(tc.b)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		scale := a.Scale()
+
+		if b.Scale() > scale {
+			scale = b.Scale()
+		}
+
+		n, err := NumberFromRat(new(big.Rat).Add(a.ToRat(), b.ToRat()), scale)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n.ToString() != tc.o {
+			t.Errorf("expected %s + %s to be %s, but got %s", tc.a, tc.b, tc.o, n.ToString())
+		}
+	}
+
+	if NumberFromNull().ToRat() != nil {
+		t.Errorf("expected null to have no value")
+	}
+}
+
+func TestNumberArithmetic(t *testing.T) {
+	cases := []struct {
+		a   string
+		b   string
+		sum string
+		mul string
+	}{
+		{a: "0.1", b: "0.2", sum: "0.3", mul: "0.02"},
+		{a: "1.5", b: "-2", sum: "-0.5", mul: "-3"},
+		{a: "123456789123456789123456789", b: "0.001", sum: "123456789123456789123456789.001", mul: "123456789123456789123456.789"},
+		{a: "", b: "7", sum: "7", mul: "0"},
+	}
+
+	for _, tc := range cases {
+		a, err := NumberFromString(tc.a)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := NumberFromString(tc.b)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sum, err := a.Add(b)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sum.ToString() != tc.sum {
+			t.Errorf("expected %s + %s to be %s, but got %s", tc.a, tc.b, tc.sum, sum.ToString())
+		}
+
+		mul, err := a.Mul(b)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mul.ToString() != tc.mul {
+			t.Errorf("expected %s * %s to be %s, but got %s", tc.a, tc.b, tc.mul, mul.ToString())
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 type Text struct {
@@ -54,6 +55,34 @@ func (a Text) Between(s DBType, l DBType) bool {
 	return a.Larger(s) && a.Smaller(l)
 }
 
+func (a Text) StartsWith(s string, caseInsensitive bool) bool {
+	t, s := a.fold(s, caseInsensitive)
+	return strings.HasPrefix(t, s) && !a.null
+}
+
+func (a Text) EndsWith(s string, caseInsensitive bool) bool {
+	t, s := a.fold(s, caseInsensitive)
+	return strings.HasSuffix(t, s) && !a.null
+}
+
+func (a Text) Contains(s string, caseInsensitive bool) bool {
+	t, s := a.fold(s, caseInsensitive)
+	return strings.Contains(t, s) && !a.null
+}
+
+func (a Text) fold(s string, caseInsensitive bool) (string, string) {
+	if caseInsensitive {
+		return strings.ToLower(a.s), strings.ToLower(s)
+	}
+
+	return a.s, s
+}
+
+// Concat returns a with s appended, a null text counts as empty
+func (a Text) Concat(s string) Text {
+	return TextFromString(a.s + s)
+}
+
 func (a Text) ToString() string {
 	if a.null {
 		return "null"
@@ -15,9 +15,15 @@ type Field struct {
 	Unique  bool                `json:"unique"`
 	Null    bool                `json:"null"`
 	Type    dbtype.DatabaseType `json:"type"`
+
+	//value of objects that were stored without this field
+	Default *string `json:"default,omitempty"`
 }
 
 type TableConfig struct {
 	Fields  map[string]Field     `json:"fields"`
 	Options request.TableOptions `json:"options"`
+
+	//fields with an index that is still being built, the build is started again when the table is loaded
+	IndexBuilds []string `json:"indexBuilds,omitempty"`
 }
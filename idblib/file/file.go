@@ -5,6 +5,7 @@
 package file
 
 import (
+	"bufio"
 	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
 	"os"
 	"sort"
@@ -136,6 +137,88 @@ func (f *File) ReadAtStartLine(start int64, readLine func(lineNumber int64, line
 	return nil
 }
 
+func (f *File) Replace(lines []string) error {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	tmpPath := f.path + ".tmp"
+
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmp)
+
+	for _, line := range lines {
+		_, err = writer.WriteString(line + "\n")
+
+		if err != nil {
+			_ = tmp.Close()
+			return err
+		}
+	}
+
+	err = writer.Flush()
+
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	err = tmp.Sync()
+
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+
+	if err != nil {
+		return err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	err = f.cachedScanner.Close()
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, f.path)
+
+	if err != nil {
+		return err
+	}
+
+	f.cachedScanner = NewCachedScanner(f.path)
+
+	return nil
+}
+
+func (f *File) Reset() error {
+	f.Lock()
+	defer f.Unlock()
+
+	err := f.cachedScanner.Close()
+
+	if err != nil {
+		return err
+	}
+
+	f.cachedScanner = NewCachedScanner(f.path)
+
+	return nil
+}
+
+func (f *File) Stat() (os.FileInfo, error) {
+	return os.Stat(f.path)
+}
+
 func (f *File) NumberOfLines() (int, error) {
 	f.Lock()
 	defer f.Unlock()
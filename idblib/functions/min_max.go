@@ -7,6 +7,7 @@ package functions
 import (
 	"encoding/json"
 	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/index"
 	"github.com/lucasl0st/InfiniteDB/idblib/object"
 	"github.com/lucasl0st/InfiniteDB/idblib/table"
 	"github.com/lucasl0st/InfiniteDB/util"
@@ -35,19 +36,64 @@ func (m *MinMaxFunction) Run(
 
 	var r dbtype.DBType
 
-	for _, o := range objects {
-		var v dbtype.DBType
+	overridden := false
 
+	for _, o := range objects {
 		if additionalFields[o][m.fieldName] != nil {
-			v = additionalFields[o][m.fieldName]
+			overridden = true
+			break
+		}
+	}
+
+	index, err := t.GetIndex(m.fieldName)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if overridden {
+		r = m.compute(objects, additionalFields, index)
+	} else if len(objects) > 0 {
+		wanted := make(map[int64]bool, len(objects))
+
+		for _, o := range objects {
+			wanted[o] = true
+		}
+
+		accept := func(id int64) bool {
+			return wanted[id]
+		}
+
+		if m.Max {
+			r, _ = index.Max(accept)
 		} else {
-			index, err := t.GetIndex(m.fieldName)
+			r, _ = index.Min(accept)
+		}
+	}
 
-			if err != nil {
-				return nil, nil, err
-			}
+	for _, o := range objects {
+		if additionalFields[o] == nil {
+			additionalFields[o] = make(map[string]dbtype.DBType)
+		}
 
-			v = index.GetValue(o)
+		additionalFields[o][m.as] = r
+	}
+
+	return objects, additionalFields, nil
+}
+
+func (m *MinMaxFunction) compute(objects object.Objects, additionalFields table.AdditionalFields, i *index.Index) dbtype.DBType {
+	var r dbtype.DBType
+
+	for _, o := range objects {
+		v := additionalFields[o][m.fieldName]
+
+		if v == nil {
+			v = i.GetValue(o)
+		}
+
+		if v == nil {
+			continue
 		}
 
 		if r == nil {
@@ -66,15 +112,7 @@ func (m *MinMaxFunction) Run(
 		}
 	}
 
-	for _, o := range objects {
-		if additionalFields[o] == nil {
-			additionalFields[o] = make(map[string]dbtype.DBType)
-		}
-
-		additionalFields[o][m.as] = r
-	}
-
-	return objects, additionalFields, nil
+	return r
 }
 
 func (m *MinMaxFunction) parseParameters(t *table.Table, parameters map[string]json.RawMessage) error {
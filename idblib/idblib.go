@@ -20,6 +20,7 @@ import (
 	"github.com/lucasl0st/InfiniteDB/models/metric"
 	"github.com/lucasl0st/InfiniteDB/models/request"
 	"github.com/lucasl0st/InfiniteDB/models/response"
+	infinitedbutil "github.com/lucasl0st/InfiniteDB/util"
 	"log"
 	"os"
 	"runtime"
@@ -34,13 +35,48 @@ type IDB struct {
 	l              util.Logger
 	m              *metrics.Metrics
 	cacheSize      uint
+	scanLimit      uint
 	watcher        *fsnotify.Watcher
 	watchDatabases bool
 	workerPool     *workerpool.WorkerPool
 	ready          bool
+
+	//transactionId -> transaction
+	transactions     map[string]*serverTransaction
+	transactionsLock sync.Mutex
+
+	//cursorId -> cursor
+	cursors     map[string]*serverCursor
+	cursorsLock sync.Mutex
+}
+
+// how long a transaction is kept without being used before it is rolled back
+const transactionTtl = time.Minute * 5
+
+// how often expired transactions and cursors are removed while the server is idle
+const expirySweepInterval = time.Minute
+
+// serverTransaction is an open transaction, it is rolled back when it is not used for transactionTtl
+type serverTransaction struct {
+	tx      *table.Transaction
+	expires time.Time
+}
+
+// serverCursor holds the request and position of a paginated read between requests, it is removed when it is not used for ttl
+type serverCursor struct {
+	name      string
+	tableName string
+	request   table.Request
+	ttl       time.Duration
+
+	//expires and fetching are guarded by cursorsLock, a cursor that is being fetched does not expire
+	expires  time.Time
+	fetching int
+
+	sync.Mutex
 }
 
-func New(databasePath string, logger util.Logger, metricsReceiver *metric.Receiver, cacheSize uint, ready func()) (*IDB, error) {
+func New(databasePath string, logger util.Logger, metricsReceiver *metric.Receiver, cacheSize uint, scanLimit uint, ready func()) (*IDB, error) {
 	if _, err := os.Stat(databasePath); errors.Is(err, os.ErrNotExist) {
 		err := os.MkdirAll(databasePath, os.ModePerm)
 
@@ -69,10 +105,13 @@ func New(databasePath string, logger util.Logger, metricsReceiver *metric.Receiv
 		l:              logger,
 		m:              metrics.New(metricsReceiver),
 		cacheSize:      cacheSize,
+		scanLimit:      scanLimit,
 		watcher:        watcher,
 		watchDatabases: true,
 		workerPool:     workerpool.New(workers),
 		ready:          false,
+		transactions:   map[string]*serverTransaction{},
+		cursors:        map[string]*serverCursor{},
 	}
 
 	go func() {
@@ -92,6 +131,14 @@ func New(databasePath string, logger util.Logger, metricsReceiver *metric.Receiv
 		}
 	}()
 
+	go func() {
+		for idb.watchDatabases {
+			time.Sleep(expirySweepInterval)
+
+			idb.removeExpired()
+		}
+	}()
+
 	return idb, nil
 }
 
@@ -210,7 +257,7 @@ func (i *IDB) loadDatabase(name string) error {
 
 	start := time.Now()
 
-	d, tables, err := database.NewDatabase(name, i.databasePath, i.l, i.m, i.cacheSize)
+	d, tables, err := database.NewDatabase(name, i.databasePath, i.l, i.m, i.cacheSize, i.scanLimit)
 
 	if err != nil {
 		return err
@@ -293,11 +340,18 @@ func (i *IDB) GetDatabaseTable(name string, tableName string) (response.GetDatab
 		return response.GetDatabaseTableResponse{}, nil
 	}
 
+	builds, err := d.IndexBuilds(tableName)
+
+	if err != nil {
+		return response.GetDatabaseTableResponse{}, err
+	}
+
 	return response.GetDatabaseTableResponse{
-		Name:      name,
-		TableName: tableName,
-		Fields:    fields,
-		Options:   *options,
+		Name:        name,
+		TableName:   tableName,
+		Fields:      fields,
+		Options:     *options,
+		IndexBuilds: indexBuildsToResponse(builds),
 	}, nil
 }
 
@@ -374,36 +428,282 @@ func (i *IDB) GetFromDatabaseTable(name string, tableName string, request table.
 		return response.GetFromDatabaseTableResponse{}, e.DatabaseDoesNotExist()
 	}
 
+	objects, next, explain, err := i.get(d, tableName, request)
+
+	if err != nil {
+		return response.GetFromDatabaseTableResponse{}, err
+	}
+
+	var nextCursor *string
+
+	if next != nil {
+		nextCursor = infinitedbutil.Ptr(next.Encode())
+	}
+
+	return response.GetFromDatabaseTableResponse{
+		Name:       name,
+		TableName:  tableName,
+		Results:    objects,
+		NextCursor: nextCursor,
+		Explain:    explainToResponse(explain),
+	}, nil
+}
+
+func (i *IDB) get(d *database.Database, tableName string, request table.Request) ([]map[string]json.RawMessage, *table.Cursor, *table.ExplainStage, error) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 
 	objectsChannel := make(chan []map[string]json.RawMessage, 1)
+	nextChannel := make(chan *table.Cursor, 1)
+	explainChannel := make(chan *table.ExplainStage, 1)
 	errChannel := make(chan error, 1)
 
 	i.workerPool.Submit(func() {
 		defer wg.Done()
 
-		objects, err := d.Get(tableName, request)
+		objects, next, explain, err := d.Get(tableName, request)
 
 		objectsChannel <- objects
+		nextChannel <- next
+		explainChannel <- explain
 		errChannel <- err
 	})
 
 	wg.Wait()
 
 	close(objectsChannel)
+	close(nextChannel)
+	close(explainChannel)
 	close(errChannel)
 
-	objects, err := <-objectsChannel, <-errChannel
+	return <-objectsChannel, <-nextChannel, <-explainChannel, <-errChannel
+}
+
+// StreamFromDatabaseTable passes the results of request to send in chunks of chunkSize objects,
+// it returns the final chunk without results that closes the stream
+func (i *IDB) StreamFromDatabaseTable(
+	name string,
+	tableName string,
+	request table.Request,
+	chunkSize int,
+	send func(chunk response.GetFromDatabaseTableChunkResponse) error,
+) (response.GetFromDatabaseTableChunkResponse, error) {
+	if !i.ready {
+		return response.GetFromDatabaseTableChunkResponse{}, e.IdbNotReady()
+	}
+
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.GetFromDatabaseTableChunkResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	var stream *database.Stream
+
+	err := i.runInPool(func() error {
+		var err error
+
+		stream, err = d.Stream(tableName, request, chunkSize)
+
+		return err
+	})
 
 	if err != nil {
-		return response.GetFromDatabaseTableResponse{}, err
+		return response.GetFromDatabaseTableChunkResponse{}, err
 	}
 
-	return response.GetFromDatabaseTableResponse{
+	var chunks, count int64
+
+	for {
+		var objects []map[string]json.RawMessage
+		var ok bool
+
+		//every chunk is read on the worker pool but sent outside of it, a slow client does not block the workers
+		err = i.runInPool(func() error {
+			var err error
+
+			objects, ok, err = stream.Next()
+
+			return err
+		})
+
+		if err != nil {
+			return response.GetFromDatabaseTableChunkResponse{}, err
+		}
+
+		if !ok {
+			break
+		}
+
+		if len(objects) == 0 {
+			continue
+		}
+
+		chunks++
+		count += int64(len(objects))
+
+		err = send(response.GetFromDatabaseTableChunkResponse{
+			Name:      name,
+			TableName: tableName,
+			Results:   objects,
+			Chunk:     chunks,
+		})
+
+		if err != nil {
+			return response.GetFromDatabaseTableChunkResponse{}, err
+		}
+	}
+
+	var nextCursor *string
+
+	if next := stream.Cursor(); next != nil {
+		nextCursor = infinitedbutil.Ptr(next.Encode())
+	}
+
+	return response.GetFromDatabaseTableChunkResponse{
+		Name:       name,
+		TableName:  tableName,
+		Results:    []map[string]json.RawMessage{},
+		Chunk:      chunks + 1,
+		Done:       true,
+		Count:      count,
+		NextCursor: nextCursor,
+		Explain:    explainToResponse(stream.Explain()),
+	}, nil
+}
+
+// runInPool runs f on the worker pool and waits for it
+func (i *IDB) runInPool(f func() error) error {
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		errChannel <- f()
+	})
+
+	wg.Wait()
+
+	return <-errChannel
+}
+
+func explainToResponse(s *table.ExplainStage) *response.ExplainStage {
+	if s == nil {
+		return nil
+	}
+
+	r := &response.ExplainStage{
+		Stage:    s.Stage,
+		Field:    s.Field,
+		Operator: s.Operator,
+		Value:    s.Value,
+		Index:    s.Index,
+		Access:   s.Access,
+		Function: s.Function,
+		Estimate: s.Estimate,
+		Before:   s.Before,
+		After:    s.After,
+		Duration: s.Duration,
+	}
+
+	for _, stage := range s.Stages {
+		r.Stages = append(r.Stages, *explainToResponse(stage))
+	}
+
+	return r
+}
+
+func (i *IDB) CountFromDatabaseTable(name string, tableName string, request table.Request) (response.CountFromDatabaseTableResponse, error) {
+	if !i.ready {
+		return response.CountFromDatabaseTableResponse{}, e.IdbNotReady()
+	}
+
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.CountFromDatabaseTableResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	countChannel := make(chan int64, 1)
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		count, err := d.Count(tableName, request)
+
+		countChannel <- count
+		errChannel <- err
+	})
+
+	wg.Wait()
+
+	count, err := <-countChannel, <-errChannel
+
+	if err != nil {
+		return response.CountFromDatabaseTableResponse{}, err
+	}
+
+	return response.CountFromDatabaseTableResponse{
 		Name:      name,
 		TableName: tableName,
-		Results:   objects,
+		Count:     count,
+	}, nil
+}
+
+func (i *IDB) ExistsInDatabaseTable(name string, tableName string, request table.Request) (response.ExistsInDatabaseTableResponse, error) {
+	if !i.ready {
+		return response.ExistsInDatabaseTableResponse{}, e.IdbNotReady()
+	}
+
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.ExistsInDatabaseTableResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	existsChannel := make(chan bool, 1)
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		exists, err := d.Exists(tableName, request)
+
+		existsChannel <- exists
+		errChannel <- err
+	})
+
+	wg.Wait()
+
+	exists, err := <-existsChannel, <-errChannel
+
+	if err != nil {
+		return response.ExistsInDatabaseTableResponse{}, err
+	}
+
+	return response.ExistsInDatabaseTableResponse{
+		Name:      name,
+		TableName: tableName,
+		Exists:    exists,
 	}, nil
 }
 
@@ -449,6 +749,70 @@ func (i *IDB) InsertToDatabaseTable(name string, tableName string, object map[st
 	}, nil
 }
 
+func (i *IDB) BulkInsertToDatabaseTable(name string, tableName string, objects []map[string]json.RawMessage) (response.BulkInsertToDatabaseTableResponse, error) {
+	if !i.ready {
+		return response.BulkInsertToDatabaseTableResponse{}, e.IdbNotReady()
+	}
+
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.BulkInsertToDatabaseTableResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	errsChannel := make(chan []error, 1)
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		errs, err := d.BulkInsert(tableName, objects)
+
+		errsChannel <- errs
+		errChannel <- err
+	})
+
+	wg.Wait()
+
+	errs, err := <-errsChannel, <-errChannel
+
+	if err != nil {
+		return response.BulkInsertToDatabaseTableResponse{}, err
+	}
+
+	var inserted int64 = 0
+	var results []response.BulkInsertResult
+
+	for k, object := range objects {
+		result := response.BulkInsertResult{
+			Object:   object,
+			Inserted: errs[k] == nil,
+		}
+
+		if errs[k] != nil {
+			message := errs[k].Error()
+			result.Error = &message
+		} else {
+			inserted++
+		}
+
+		results = append(results, result)
+	}
+
+	return response.BulkInsertToDatabaseTableResponse{
+		Name:      name,
+		TableName: tableName,
+		Inserted:  inserted,
+		Results:   results,
+	}, nil
+}
+
 func (i *IDB) RemoveFromDatabaseTable(name string, tableName string, request table.Request) (response.RemoveFromDatabaseTableResponse, error) {
 	if !i.ready {
 		return response.RemoveFromDatabaseTableResponse{}, e.IdbNotReady()
@@ -507,6 +871,8 @@ func (i *IDB) UpdateInDatabaseTable(name string, tableName string, object map[st
 	errChannel := make(chan error, 1)
 
 	i.workerPool.Submit(func() {
+		defer wg.Done()
+
 		err := d.Update(tableName, object)
 
 		errChannel <- err
@@ -526,3 +892,721 @@ func (i *IDB) UpdateInDatabaseTable(name string, tableName string, object map[st
 		Object:    object,
 	}, nil
 }
+
+func (i *IDB) UpsertToDatabaseTable(name string, tableName string, object map[string]json.RawMessage) (response.UpsertToDatabaseTableResponse, error) {
+	if !i.ready {
+		return response.UpsertToDatabaseTableResponse{}, e.IdbNotReady()
+	}
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.UpsertToDatabaseTableResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	insertedChannel := make(chan bool, 1)
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		inserted, err := d.Upsert(tableName, object)
+
+		insertedChannel <- inserted
+		errChannel <- err
+	})
+
+	wg.Wait()
+
+	inserted, err := <-insertedChannel, <-errChannel
+
+	if err != nil {
+		return response.UpsertToDatabaseTableResponse{}, err
+	}
+
+	action := response.UPDATED
+
+	if inserted {
+		action = response.INSERTED
+	}
+
+	return response.UpsertToDatabaseTableResponse{
+		Name:      name,
+		TableName: tableName,
+		Action:    action,
+		Object:    object,
+	}, nil
+}
+
+func (i *IDB) UpdateWhereInDatabaseTable(
+	name string,
+	tableName string,
+	query table.Query,
+	updates []table.FieldUpdate,
+) (response.UpdateWhereInDatabaseTableResponse, error) {
+	if !i.ready {
+		return response.UpdateWhereInDatabaseTableResponse{}, e.IdbNotReady()
+	}
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.UpdateWhereInDatabaseTableResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	var matched, modified int64
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		var err error
+		matched, modified, err = d.UpdateWhere(tableName, query, updates)
+
+		errChannel <- err
+	})
+
+	wg.Wait()
+
+	err := <-errChannel
+
+	if err != nil {
+		return response.UpdateWhereInDatabaseTableResponse{}, err
+	}
+
+	return response.UpdateWhereInDatabaseTableResponse{
+		Name:      name,
+		TableName: tableName,
+		Matched:   matched,
+		Modified:  modified,
+	}, nil
+}
+
+func (i *IDB) CompactDatabaseTable(name string, tableName string) (response.CompactDatabaseTableResponse, error) {
+	if !i.ready {
+		return response.CompactDatabaseTableResponse{}, e.IdbNotReady()
+	}
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.CompactDatabaseTableResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	var before, after int64
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		var err error
+		before, after, err = d.Compact(tableName)
+
+		errChannel <- err
+	})
+
+	wg.Wait()
+
+	err := <-errChannel
+
+	if err != nil {
+		return response.CompactDatabaseTableResponse{}, err
+	}
+
+	return response.CompactDatabaseTableResponse{
+		Name:         name,
+		TableName:    tableName,
+		Message:      "Compacted table in database",
+		EventsBefore: before,
+		EventsAfter:  after,
+	}, nil
+}
+
+func (i *IDB) AlterTable(name string, tableName string, changes []table.AlterTableChange) (response.AlterTableResponse, error) {
+	if !i.ready {
+		return response.AlterTableResponse{}, e.IdbNotReady()
+	}
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.AlterTableResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		errChannel <- d.AlterTable(tableName, changes)
+	})
+
+	wg.Wait()
+
+	err := <-errChannel
+
+	if err != nil {
+		return response.AlterTableResponse{}, err
+	}
+
+	fields, _, err := d.GetTable(tableName)
+
+	if err != nil {
+		return response.AlterTableResponse{}, err
+	}
+
+	return response.AlterTableResponse{
+		Name:      name,
+		TableName: tableName,
+		Message:   "Altered table",
+		Fields:    fields,
+	}, nil
+}
+
+func (i *IDB) CreateIndex(name string, tableName string, fieldName string) (response.CreateIndexResponse, error) {
+	if !i.ready {
+		return response.CreateIndexResponse{}, e.IdbNotReady()
+	}
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.CreateIndexResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	progress, err := d.CreateIndex(tableName, fieldName)
+
+	if err != nil {
+		return response.CreateIndexResponse{}, err
+	}
+
+	return response.CreateIndexResponse{
+		Name:      name,
+		TableName: tableName,
+		FieldName: fieldName,
+		Message:   "Started building index",
+		Progress: response.IndexBuildProgress{
+			Processed: progress.Processed,
+			Total:     progress.Total,
+		},
+	}, nil
+}
+
+func (i *IDB) DropIndex(name string, tableName string, fieldName string) (response.DropIndexResponse, error) {
+	if !i.ready {
+		return response.DropIndexResponse{}, e.IdbNotReady()
+	}
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.DropIndexResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	err := d.DropIndex(tableName, fieldName)
+
+	if err != nil {
+		return response.DropIndexResponse{}, err
+	}
+
+	return response.DropIndexResponse{
+		Name:      name,
+		TableName: tableName,
+		FieldName: fieldName,
+		Message:   "Dropped index",
+	}, nil
+}
+
+func indexBuildsToResponse(builds map[string]table.IndexBuildProgress) map[string]response.IndexBuildProgress {
+	if len(builds) == 0 {
+		return nil
+	}
+
+	r := map[string]response.IndexBuildProgress{}
+
+	for fieldName, progress := range builds {
+		r[fieldName] = response.IndexBuildProgress{
+			Processed: progress.Processed,
+			Total:     progress.Total,
+		}
+	}
+
+	return r
+}
+
+func (i *IDB) BeginTransaction(name string, tableName string) (response.BeginTransactionResponse, error) {
+	if !i.ready {
+		return response.BeginTransactionResponse{}, e.IdbNotReady()
+	}
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.BeginTransactionResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	tx, err := d.Begin(tableName)
+
+	if err != nil {
+		return response.BeginTransactionResponse{}, err
+	}
+
+	i.transactionsLock.Lock()
+	defer i.transactionsLock.Unlock()
+
+	i.removeExpiredTransactions()
+
+	transactionId := infinitedbutil.RandomString(32)
+
+	for i.transactions[transactionId] != nil {
+		transactionId = infinitedbutil.RandomString(32)
+	}
+
+	i.transactions[transactionId] = &serverTransaction{
+		tx:      tx,
+		expires: time.Now().Add(transactionTtl),
+	}
+
+	return response.BeginTransactionResponse{
+		Name:          name,
+		TableName:     tableName,
+		TransactionId: transactionId,
+		Message:       "Began transaction",
+	}, nil
+}
+
+func (i *IDB) CommitTransaction(transactionId string) (response.CommitTransactionResponse, error) {
+	if !i.ready {
+		return response.CommitTransactionResponse{}, e.IdbNotReady()
+	}
+
+	tx := i.endTransaction(transactionId)
+
+	if tx == nil {
+		return response.CommitTransactionResponse{}, e.TransactionDoesNotExist()
+	}
+
+	operations := tx.Operations()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		errChannel <- tx.Commit()
+	})
+
+	wg.Wait()
+
+	err := <-errChannel
+
+	if err != nil {
+		return response.CommitTransactionResponse{}, err
+	}
+
+	return response.CommitTransactionResponse{
+		Name:          tx.Table.DatabaseName,
+		TableName:     tx.Table.Name,
+		TransactionId: transactionId,
+		Message:       "Committed transaction",
+		Operations:    operations,
+	}, nil
+}
+
+func (i *IDB) RollbackTransaction(transactionId string) (response.RollbackTransactionResponse, error) {
+	if !i.ready {
+		return response.RollbackTransactionResponse{}, e.IdbNotReady()
+	}
+
+	tx := i.endTransaction(transactionId)
+
+	if tx == nil {
+		return response.RollbackTransactionResponse{}, e.TransactionDoesNotExist()
+	}
+
+	tx.Rollback()
+
+	return response.RollbackTransactionResponse{
+		Name:          tx.Table.DatabaseName,
+		TableName:     tx.Table.Name,
+		TransactionId: transactionId,
+		Message:       "Rolled back transaction",
+	}, nil
+}
+
+func (i *IDB) InsertToDatabaseTableInTransaction(transactionId string, name *string, tableName *string, object map[string]json.RawMessage) (response.InsertToDatabaseTableResponse, error) {
+	if !i.ready {
+		return response.InsertToDatabaseTableResponse{}, e.IdbNotReady()
+	}
+
+	tx, err := i.getTransactionOn(transactionId, name, tableName)
+
+	if err != nil {
+		return response.InsertToDatabaseTableResponse{}, err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		err := tx.Insert(object)
+
+		errChannel <- err
+	})
+
+	wg.Wait()
+
+	err = <-errChannel
+
+	if err != nil {
+		return response.InsertToDatabaseTableResponse{}, err
+	}
+
+	return response.InsertToDatabaseTableResponse{
+		Name:          tx.Table.DatabaseName,
+		TableName:     tx.Table.Name,
+		TransactionId: &transactionId,
+		Object:        object,
+	}, nil
+}
+
+func (i *IDB) RemoveFromDatabaseTableInTransaction(transactionId string, name *string, tableName *string, request table.Request) (response.RemoveFromDatabaseTableResponse, error) {
+	if !i.ready {
+		return response.RemoveFromDatabaseTableResponse{}, e.IdbNotReady()
+	}
+
+	tx, err := i.getTransactionOn(transactionId, name, tableName)
+
+	if err != nil {
+		return response.RemoveFromDatabaseTableResponse{}, err
+	}
+
+	d := i.databases[tx.Table.DatabaseName]
+
+	if d == nil {
+		return response.RemoveFromDatabaseTableResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	countChannel := make(chan int64, 1)
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		count, err := d.RemoveInTransaction(tx, request)
+
+		countChannel <- count
+		errChannel <- err
+	})
+
+	wg.Wait()
+
+	count, err := <-countChannel, <-errChannel
+
+	if err != nil {
+		return response.RemoveFromDatabaseTableResponse{}, err
+	}
+
+	return response.RemoveFromDatabaseTableResponse{
+		Name:          tx.Table.DatabaseName,
+		TableName:     tx.Table.Name,
+		TransactionId: &transactionId,
+		Removed:       count,
+	}, nil
+}
+
+func (i *IDB) UpdateInDatabaseTableInTransaction(transactionId string, name *string, tableName *string, object map[string]json.RawMessage) (response.UpdateInDatabaseTableResponse, error) {
+	if !i.ready {
+		return response.UpdateInDatabaseTableResponse{}, e.IdbNotReady()
+	}
+
+	tx, err := i.getTransactionOn(transactionId, name, tableName)
+
+	if err != nil {
+		return response.UpdateInDatabaseTableResponse{}, err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	errChannel := make(chan error, 1)
+
+	i.workerPool.Submit(func() {
+		defer wg.Done()
+
+		err := tx.Update(object)
+
+		errChannel <- err
+	})
+
+	wg.Wait()
+
+	err = <-errChannel
+
+	if err != nil {
+		return response.UpdateInDatabaseTableResponse{}, err
+	}
+
+	return response.UpdateInDatabaseTableResponse{
+		Name:          tx.Table.DatabaseName,
+		TableName:     tx.Table.Name,
+		TransactionId: &transactionId,
+		Object:        object,
+	}, nil
+}
+
+// getTransaction returns the transaction and keeps it open for another transactionTtl
+func (i *IDB) getTransaction(transactionId string) *table.Transaction {
+	i.transactionsLock.Lock()
+	defer i.transactionsLock.Unlock()
+
+	i.removeExpiredTransactions()
+
+	t := i.transactions[transactionId]
+
+	if t == nil {
+		return nil
+	}
+
+	t.expires = time.Now().Add(transactionTtl)
+
+	return t.tx
+}
+
+// getTransactionOn returns the transaction if it was begun on the database and table of the request,
+// name and tableName are nil if the request only addresses the transaction
+func (i *IDB) getTransactionOn(transactionId string, name *string, tableName *string) (*table.Transaction, error) {
+	tx := i.getTransaction(transactionId)
+
+	if tx == nil {
+		return nil, e.TransactionDoesNotExist()
+	}
+
+	if (name != nil && *name != tx.Table.DatabaseName) || (tableName != nil && *tableName != tx.Table.Name) {
+		return nil, e.TransactionIsOnOtherTable(tx.Table.DatabaseName, tx.Table.Name)
+	}
+
+	return tx, nil
+}
+
+func (i *IDB) endTransaction(transactionId string) *table.Transaction {
+	i.transactionsLock.Lock()
+	defer i.transactionsLock.Unlock()
+
+	i.removeExpiredTransactions()
+
+	t := i.transactions[transactionId]
+	delete(i.transactions, transactionId)
+
+	if t == nil {
+		return nil
+	}
+
+	return t.tx
+}
+
+// removeExpired rolls back expired transactions and removes expired cursors,
+// otherwise they would only be removed by the next request using transactions or cursors
+func (i *IDB) removeExpired() {
+	i.transactionsLock.Lock()
+	i.removeExpiredTransactions()
+	i.transactionsLock.Unlock()
+
+	i.cursorsLock.Lock()
+	i.removeExpiredCursors()
+	i.cursorsLock.Unlock()
+}
+
+func (i *IDB) removeExpiredTransactions() {
+	now := time.Now()
+
+	for transactionId, t := range i.transactions {
+		if now.After(t.expires) {
+			t.tx.Rollback()
+			delete(i.transactions, transactionId)
+		}
+	}
+}
+
+func (i *IDB) OpenCursor(name string, tableName string, request table.Request, ttl time.Duration) (response.OpenCursorResponse, error) {
+	if !i.ready {
+		return response.OpenCursorResponse{}, e.IdbNotReady()
+	}
+
+	d := i.databases[name]
+
+	if d == nil {
+		return response.OpenCursorResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	if request.Aggregate != nil {
+		return response.OpenCursorResponse{}, e.CannotUseCursorWithAggregate()
+	}
+
+	if request.Skip != nil {
+		return response.OpenCursorResponse{}, e.CannotSkipWithCursor()
+	}
+
+	if request.Cursor == nil {
+		request.Cursor = infinitedbutil.Ptr(table.NewCursor(request.Sort))
+	}
+
+	i.cursorsLock.Lock()
+	defer i.cursorsLock.Unlock()
+
+	i.removeExpiredCursors()
+
+	cursorId := infinitedbutil.RandomString(32)
+
+	for i.cursors[cursorId] != nil {
+		cursorId = infinitedbutil.RandomString(32)
+	}
+
+	c := &serverCursor{
+		name:      name,
+		tableName: tableName,
+		request:   request,
+		ttl:       ttl,
+		expires:   time.Now().Add(ttl),
+	}
+
+	i.cursors[cursorId] = c
+
+	return response.OpenCursorResponse{
+		Name:      name,
+		TableName: tableName,
+		CursorId:  cursorId,
+		Expires:   c.expires,
+		Message:   "Opened cursor",
+	}, nil
+}
+
+// FetchCursor returns the next page of the cursor, the cursor is closed after the last page
+func (i *IDB) FetchCursor(cursorId string) (response.FetchCursorResponse, error) {
+	if !i.ready {
+		return response.FetchCursorResponse{}, e.IdbNotReady()
+	}
+
+	c := i.useCursor(cursorId)
+
+	if c == nil {
+		return response.FetchCursorResponse{}, e.CursorDoesNotExist()
+	}
+
+	defer i.releaseCursor(c)
+
+	c.Lock()
+	defer c.Unlock()
+
+	d := i.databases[c.name]
+
+	if d == nil {
+		return response.FetchCursorResponse{}, e.DatabaseDoesNotExist()
+	}
+
+	objects, next, _, err := i.get(d, c.tableName, c.request)
+
+	if err != nil {
+		return response.FetchCursorResponse{}, err
+	}
+
+	if next == nil {
+		i.closeCursor(cursorId)
+	} else {
+		c.request.Cursor = next
+	}
+
+	return response.FetchCursorResponse{
+		Name:      c.name,
+		TableName: c.tableName,
+		CursorId:  cursorId,
+		Results:   objects,
+		Done:      next == nil,
+	}, nil
+}
+
+func (i *IDB) CloseCursor(cursorId string) (response.CloseCursorResponse, error) {
+	if !i.ready {
+		return response.CloseCursorResponse{}, e.IdbNotReady()
+	}
+
+	c := i.closeCursor(cursorId)
+
+	if c == nil {
+		return response.CloseCursorResponse{}, e.CursorDoesNotExist()
+	}
+
+	return response.CloseCursorResponse{
+		Name:      c.name,
+		TableName: c.tableName,
+		CursorId:  cursorId,
+		Message:   "Closed cursor",
+	}, nil
+}
+
+// useCursor returns the cursor and keeps it from expiring until releaseCursor is called
+func (i *IDB) useCursor(cursorId string) *serverCursor {
+	i.cursorsLock.Lock()
+	defer i.cursorsLock.Unlock()
+
+	i.removeExpiredCursors()
+
+	c := i.cursors[cursorId]
+
+	if c != nil {
+		c.fetching++
+	}
+
+	return c
+}
+
+// releaseCursor ends a use of the cursor, it expires ttl after its last use
+func (i *IDB) releaseCursor(c *serverCursor) {
+	i.cursorsLock.Lock()
+	defer i.cursorsLock.Unlock()
+
+	c.fetching--
+	c.expires = time.Now().Add(c.ttl)
+}
+
+func (i *IDB) closeCursor(cursorId string) *serverCursor {
+	i.cursorsLock.Lock()
+	defer i.cursorsLock.Unlock()
+
+	c := i.cursors[cursorId]
+	delete(i.cursors, cursorId)
+
+	return c
+}
+
+func (i *IDB) removeExpiredCursors() {
+	now := time.Now()
+
+	for cursorId, c := range i.cursors {
+		if c.fetching == 0 && now.After(c.expires) {
+			delete(i.cursors, cursorId)
+		}
+	}
+}
@@ -54,3 +54,11 @@ func (i *ExactIndex) Get(value dbtype.DBType) []int64 {
 
 	return i.values[value.ToString()]
 }
+
+// Count returns the number of objects with value without copying them
+func (i *ExactIndex) Count(value dbtype.DBType) int {
+	i.RLock()
+	defer i.RUnlock()
+
+	return len(i.values[value.ToString()])
+}
@@ -16,24 +16,33 @@ type Index struct {
 }
 
 func NewIndex() *Index {
-	index := &Index{
-		valueIndex: NewValueIndex(),
-		exactIndex: NewExactIndex(),
+	return &Index{
+		valueIndex:  NewValueIndex(),
+		exactIndex:  NewExactIndex(),
+		sortedIndex: NewSortedIndex(),
 	}
-
-	index.sortedIndex = NewSortedIndex(index.valueIndex.GetValue)
-
-	return index
 }
 
 func (i *Index) Add(value dbtype.DBType, id int64) {
+	//the sorted index is keyed by value, drop a previous entry so it does not linger
+	if previous := i.valueIndex.GetValue(id); previous != nil {
+		i.sortedIndex.Remove(previous, id)
+		i.exactIndex.Remove(previous, id)
+	}
+
 	i.valueIndex.Add(value, id)
 	i.exactIndex.Add(value, id)
-	i.sortedIndex.Add(id)
+	i.sortedIndex.Add(value, id)
 }
 
-func (i *Index) Remove(value dbtype.DBType, id int64) {
-	i.sortedIndex.Remove(id)
+func (i *Index) Remove(id int64) {
+	value := i.valueIndex.GetValue(id)
+
+	if value == nil {
+		return
+	}
+
+	i.sortedIndex.Remove(value, id)
 	i.exactIndex.Remove(value, id)
 	i.valueIndex.Remove(id)
 }
@@ -42,6 +51,10 @@ func (i *Index) GetValue(id int64) dbtype.DBType {
 	return i.valueIndex.GetValue(id)
 }
 
+func (i *Index) Values() map[int64]dbtype.DBType {
+	return i.valueIndex.Values()
+}
+
 func (i *Index) Equal(value dbtype.DBType) []int64 {
 	return i.exactIndex.Get(value)
 }
@@ -66,8 +79,144 @@ func (i *Index) Smaller(value dbtype.DBType) []int64 {
 	return i.sortedIndex.Smaller(value)
 }
 
-func (i *Index) Between(smaller dbtype.DBType, larger dbtype.DBType) []int64 {
+func (i *Index) LargerOrEqual(value dbtype.DBType) []int64 {
+	return i.sortedIndex.LargerOrEqual(value)
+}
+
+func (i *Index) SmallerOrEqual(value dbtype.DBType) []int64 {
+	return i.sortedIndex.SmallerOrEqual(value)
+}
+
+func (i *Index) In(values []dbtype.DBType) []int64 {
+	var results []int64
+
+	seen := map[string]bool{}
+
+	for _, value := range values {
+		if seen[value.ToString()] {
+			continue
+		}
+
+		seen[value.ToString()] = true
+
+		results = append(results, i.exactIndex.Get(value)...)
+	}
+
+	return results
+}
+
+// CountEqual returns the number of objects Equal would return
+func (i *Index) CountEqual(value dbtype.DBType) int {
+	return i.exactIndex.Count(value)
+}
+
+// CountIn returns the number of objects In would return
+func (i *Index) CountIn(values []dbtype.DBType) int {
+	count := 0
+
+	seen := map[string]bool{}
+
+	for _, value := range values {
+		if seen[value.ToString()] {
+			continue
+		}
+
+		seen[value.ToString()] = true
+
+		count += i.exactIndex.Count(value)
+	}
+
+	return count
+}
+
+func (i *Index) NotIn(values []dbtype.DBType) []int64 {
+	return i.valueIndex.Range(func(compareValue dbtype.DBType) bool {
+		for _, value := range values {
+			if !compareValue.Not(value) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+func (i *Index) IsNull() []int64 {
+	return i.sortedIndex.Nulls()
+}
+
+func (i *Index) IsNotNull() []int64 {
+	return i.sortedIndex.collect(nil, nil)
+}
+
+// StartsWith walks the sorted index from the prefix on, case-insensitive prefixes are spread over the index and have to be compared with every value
+func (i *Index) StartsWith(prefix string, caseInsensitive bool) []int64 {
+	if caseInsensitive {
+		return i.valueIndex.Range(func(compareValue dbtype.DBType) bool {
+			return compareValue.(dbtype.Text).StartsWith(prefix, true)
+		})
+	}
+
+	var results []int64
+
+	i.sortedIndex.Between(&Bound{Value: dbtype.TextFromString(prefix), Inclusive: true}, nil, func(value dbtype.DBType, id int64) bool {
+		if !value.(dbtype.Text).StartsWith(prefix, false) {
+			return false
+		}
+
+		results = append(results, id)
+		return true
+	})
+
+	return results
+}
+
+func (i *Index) EndsWith(suffix string, caseInsensitive bool) []int64 {
 	return i.valueIndex.Range(func(compareValue dbtype.DBType) bool {
-		return compareValue.Between(smaller, larger)
+		return compareValue.(dbtype.Text).EndsWith(suffix, caseInsensitive)
 	})
 }
+
+func (i *Index) Contains(s string, caseInsensitive bool) []int64 {
+	return i.valueIndex.Range(func(compareValue dbtype.DBType) bool {
+		return compareValue.(dbtype.Text).Contains(s, caseInsensitive)
+	})
+}
+
+func (i *Index) Between(smaller dbtype.DBType, larger dbtype.DBType) []int64 {
+	return i.sortedIndex.collect(&Bound{Value: smaller}, &Bound{Value: larger})
+}
+
+func (i *Index) Range(lower *Bound, upper *Bound, iterator func(value dbtype.DBType, id int64) bool) {
+	i.sortedIndex.Between(lower, upper, iterator)
+}
+
+func (i *Index) Ascend(iterator func(value dbtype.DBType, id int64) bool) {
+	i.sortedIndex.Ascend(iterator)
+}
+
+func (i *Index) Descend(iterator func(value dbtype.DBType, id int64) bool) {
+	i.sortedIndex.Descend(iterator)
+}
+
+func (i *Index) AscendAfter(value dbtype.DBType, id int64, iterator func(value dbtype.DBType, id int64) bool) {
+	i.sortedIndex.AscendAfter(value, id, iterator)
+}
+
+func (i *Index) DescendBefore(value dbtype.DBType, id int64, iterator func(value dbtype.DBType, id int64) bool) {
+	i.sortedIndex.DescendBefore(value, id, iterator)
+}
+
+func (i *Index) Min(accept func(id int64) bool) (dbtype.DBType, bool) {
+	value, _, ok := i.sortedIndex.Min(accept)
+	return value, ok
+}
+
+func (i *Index) Max(accept func(id int64) bool) (dbtype.DBType, bool) {
+	value, _, ok := i.sortedIndex.Max(accept)
+	return value, ok
+}
+
+func (i *Index) Len() int {
+	return i.sortedIndex.Len()
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package index
+
+import (
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"sort"
+	"testing"
+)
+
+func TestIndexOperators(t *testing.T) {
+	i := NewIndex()
+
+	for id := int64(0); id < 10; id++ {
+		i.Add(number(t, id%5), id)
+	}
+
+	i.Add(dbtype.NumberFromNull(), 10)
+
+	cases := []struct {
+		name     string
+		results  []int64
+		expected []int64
+	}{
+		{name: ">=", results: i.LargerOrEqual(number(t, 3)), expected: []int64{3, 4, 8, 9}},
+		{name: "<=", results: i.SmallerOrEqual(number(t, 1)), expected: []int64{0, 1, 5, 6}},
+		{name: "in", results: i.In([]dbtype.DBType{number(t, 0), number(t, 2), number(t, 2)}), expected: []int64{0, 2, 5, 7}},
+		{name: "notIn", results: i.NotIn([]dbtype.DBType{number(t, 0), number(t, 1), number(t, 2)}), expected: []int64{3, 4, 8, 9, 10}},
+		{name: "isNull", results: i.IsNull(), expected: []int64{10}},
+		{name: "isNotNull", results: i.IsNotNull(), expected: []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+	}
+
+	for _, tc := range cases {
+		sort.Slice(tc.results, func(a, b int) bool {
+			return tc.results[a] < tc.results[b]
+		})
+
+		if len(tc.results) != len(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, tc.results)
+			continue
+		}
+
+		assertIds(t, tc.expected, tc.results)
+	}
+}
+
+func TestIndexTextOperators(t *testing.T) {
+	i := NewIndex()
+
+	for id, s := range []string{"apple", "Apricot", "banana", "application", "grape", "ap"} {
+		i.Add(dbtype.TextFromString(s), int64(id))
+	}
+
+	i.Add(dbtype.TextFromNull(), 6)
+
+	cases := []struct {
+		name     string
+		results  []int64
+		expected []int64
+	}{
+		{name: "startsWith", results: i.StartsWith("ap", false), expected: []int64{0, 3, 5}},
+		{name: "startsWith case-insensitive", results: i.StartsWith("AP", true), expected: []int64{0, 1, 3, 5}},
+		{name: "endsWith", results: i.EndsWith("e", false), expected: []int64{0, 4}},
+		{name: "contains", results: i.Contains("an", false), expected: []int64{2}},
+		{name: "contains case-insensitive", results: i.Contains("PP", true), expected: []int64{0, 3}},
+	}
+
+	for _, tc := range cases {
+		sort.Slice(tc.results, func(a, b int) bool {
+			return tc.results[a] < tc.results[b]
+		})
+
+		if len(tc.results) != len(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, tc.results)
+			continue
+		}
+
+		assertIds(t, tc.expected, tc.results)
+	}
+}
+
+func TestIndexCounts(t *testing.T) {
+	i := NewIndex()
+
+	for id := int64(0); id < 10; id++ {
+		i.Add(number(t, id%5), id)
+	}
+
+	i.Remove(0)
+
+	if count := i.CountEqual(number(t, 0)); count != 1 {
+		t.Errorf("equal: expected 1, got %d", count)
+	}
+
+	if count := i.CountEqual(number(t, 7)); count != 0 {
+		t.Errorf("equal missing value: expected 0, got %d", count)
+	}
+
+	values := []dbtype.DBType{number(t, 1), number(t, 2), number(t, 2), number(t, 7)}
+
+	if count := i.CountIn(values); count != len(i.In(values)) || count != 4 {
+		t.Errorf("in: expected 4, got %d", count)
+	}
+}
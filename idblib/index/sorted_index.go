@@ -6,103 +6,547 @@ package index
 
 import (
 	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"math"
 	"sort"
 	"sync"
 )
 
-type SortedIndex struct {
-	values []int64
+const degree = 32
+
+const maxEntries = 2*degree - 1
+const minEntries = degree - 1
 
-	sorted bool
+type Bound struct {
+	Value     dbtype.DBType
+	Inclusive bool
+}
 
-	getValue func(id int64) dbtype.DBType
+type entry struct {
+	value dbtype.DBType
+	id    int64
+}
+
+type node struct {
+	entries  []entry
+	children []*node
+}
+
+type SortedIndex struct {
+	root   *node
+	length int
 
 	sync.RWMutex
 }
 
-func NewSortedIndex(getValue func(id int64) dbtype.DBType) *SortedIndex {
-	return &SortedIndex{
-		sorted:   true,
-		getValue: getValue,
-	}
+func NewSortedIndex() *SortedIndex {
+	return &SortedIndex{}
 }
 
-func (i *SortedIndex) Add(id int64) {
+func (i *SortedIndex) Add(value dbtype.DBType, id int64) {
 	i.Lock()
 	defer i.Unlock()
 
-	i.sorted = false
-	i.values = append(i.values, id)
+	e := entry{value: value, id: id}
+
+	if i.root == nil {
+		i.root = &node{entries: []entry{e}}
+		i.length++
+		return
+	}
+
+	if len(i.root.entries) >= maxEntries {
+		middle, right := i.root.split(maxEntries / 2)
+
+		i.root = &node{
+			entries:  []entry{middle},
+			children: []*node{i.root, right},
+		}
+	}
+
+	if i.root.insert(e) {
+		i.length++
+	}
 }
 
-func (i *SortedIndex) Remove(id int64) {
+func (i *SortedIndex) Remove(value dbtype.DBType, id int64) {
 	i.Lock()
 	defer i.Unlock()
 
-	var removed []int64
+	if i.root == nil {
+		return
+	}
+
+	if i.root.remove(entry{value: value, id: id}) {
+		i.length--
+	}
 
-	for _, compareId := range i.values {
-		if compareId != id {
-			removed = append(removed, compareId)
+	if len(i.root.entries) == 0 {
+		if len(i.root.children) > 0 {
+			i.root = i.root.children[0]
+		} else {
+			i.root = nil
 		}
 	}
+}
+
+func (i *SortedIndex) Len() int {
+	i.RLock()
+	defer i.RUnlock()
 
-	i.values = removed
+	return i.length
 }
 
-func (i *SortedIndex) sort() {
-	i.Lock()
-	defer i.Unlock()
+// Ascend calls iterator for all entries in ascending order, null values come last
+func (i *SortedIndex) Ascend(iterator func(value dbtype.DBType, id int64) bool) {
+	i.RLock()
+	defer i.RUnlock()
+
+	if i.root == nil {
+		return
+	}
 
-	sort.Slice(i.values, func(j, k int) bool {
-		return i.getValue(i.values[j]).Smaller(i.getValue(i.values[k]))
+	i.root.ascend(nil, true, func(e entry) bool {
+		return iterator(e.value, e.id)
 	})
+}
 
-	i.sorted = true
+// Descend calls iterator for all entries in descending order, null values come first
+func (i *SortedIndex) Descend(iterator func(value dbtype.DBType, id int64) bool) {
+	i.RLock()
+	defer i.RUnlock()
+
+	if i.root == nil {
+		return
+	}
+
+	i.root.descend(nil, true, func(e entry) bool {
+		return iterator(e.value, e.id)
+	})
 }
 
-func (i *SortedIndex) Larger(value dbtype.DBType) []int64 {
-	if !i.sorted {
-		i.sort()
+// AscendAfter calls iterator in ascending order for all non-null entries after value and id, a nil value starts at the smallest entry
+func (i *SortedIndex) AscendAfter(value dbtype.DBType, id int64, iterator func(value dbtype.DBType, id int64) bool) {
+	i.RLock()
+	defer i.RUnlock()
+
+	if i.root == nil {
+		return
+	}
+
+	var start *entry
+
+	if !isNull(value) {
+		start = &entry{value: value, id: id}
+	}
+
+	i.root.ascend(start, false, func(e entry) bool {
+		if isNull(e.value) {
+			return false
+		}
+
+		return iterator(e.value, e.id)
+	})
+}
+
+// DescendBefore calls iterator in descending order for all non-null entries before value and id, a nil value starts at the largest entry
+func (i *SortedIndex) DescendBefore(value dbtype.DBType, id int64, iterator func(value dbtype.DBType, id int64) bool) {
+	i.RLock()
+	defer i.RUnlock()
+
+	if i.root == nil {
+		return
 	}
 
+	//null values are sorted last, start right before the first of them
+	start := &entry{value: nil, id: math.MinInt64}
+
+	if !isNull(value) {
+		start = &entry{value: value, id: id}
+	}
+
+	i.root.descend(start, false, func(e entry) bool {
+		return iterator(e.value, e.id)
+	})
+}
+
+// Between calls iterator in ascending order for all non-null entries within the bounds, a nil bound is open
+func (i *SortedIndex) Between(lower *Bound, upper *Bound, iterator func(value dbtype.DBType, id int64) bool) {
 	i.RLock()
 	defer i.RUnlock()
 
-	for k, compareId := range i.values {
-		if i.getValue(compareId).Larger(value) {
-			var results []int64
+	if i.root == nil {
+		return
+	}
+
+	var start *entry
+	inclusive := true
+
+	if lower != nil {
+		if lower.Inclusive {
+			start = &entry{value: lower.Value, id: math.MinInt64}
+		} else {
+			start = &entry{value: lower.Value, id: math.MaxInt64}
+			inclusive = false
+		}
+	}
+
+	i.root.ascend(start, inclusive, func(e entry) bool {
+		if isNull(e.value) {
+			return false
+		}
+
+		if upper != nil {
+			c := compareValues(e.value, upper.Value)
 
-			for j := k; j < len(i.values); j++ {
-				results = append(results, i.values[j])
+			if c > 0 || (c == 0 && !upper.Inclusive) {
+				return false
 			}
+		}
 
-			return results
+		return iterator(e.value, e.id)
+	})
+}
+
+// Min returns the smallest non-null entry for which accept returns true
+func (i *SortedIndex) Min(accept func(id int64) bool) (dbtype.DBType, int64, bool) {
+	var value dbtype.DBType
+	var id int64
+	found := false
+
+	i.Between(nil, nil, func(v dbtype.DBType, objectId int64) bool {
+		if !accept(objectId) {
+			return true
 		}
+
+		value, id, found = v, objectId, true
+
+		return false
+	})
+
+	return value, id, found
+}
+
+// Max returns the largest non-null entry for which accept returns true
+func (i *SortedIndex) Max(accept func(id int64) bool) (dbtype.DBType, int64, bool) {
+	i.RLock()
+	defer i.RUnlock()
+
+	var value dbtype.DBType
+	var id int64
+	found := false
+
+	if i.root == nil {
+		return value, id, found
 	}
 
-	return nil
+	//null values are sorted last, start right before the first of them
+	i.root.descend(&entry{value: nil, id: math.MinInt64}, false, func(e entry) bool {
+		if !accept(e.id) {
+			return true
+		}
+
+		value, id, found = e.value, e.id, true
+
+		return false
+	})
+
+	return value, id, found
+}
+
+func (i *SortedIndex) Larger(value dbtype.DBType) []int64 {
+	return i.collect(&Bound{Value: value, Inclusive: false}, nil)
 }
 
 func (i *SortedIndex) Smaller(value dbtype.DBType) []int64 {
-	if !i.sorted {
-		i.sort()
-	}
+	return i.collect(nil, &Bound{Value: value, Inclusive: false})
+}
+
+func (i *SortedIndex) LargerOrEqual(value dbtype.DBType) []int64 {
+	return i.collect(&Bound{Value: value, Inclusive: true}, nil)
+}
+
+func (i *SortedIndex) SmallerOrEqual(value dbtype.DBType) []int64 {
+	return i.collect(nil, &Bound{Value: value, Inclusive: true})
+}
 
+// Nulls returns the ids of all entries with a null value, they are sorted last
+func (i *SortedIndex) Nulls() []int64 {
 	i.RLock()
 	defer i.RUnlock()
 
-	for k := len(i.values) - 1; k >= 0; k-- {
-		if i.getValue(i.values[k]).Smaller(value) {
-			var results []int64
+	var results []int64
+
+	if i.root == nil {
+		return results
+	}
+
+	i.root.ascend(&entry{value: nil, id: math.MinInt64}, true, func(e entry) bool {
+		results = append(results, e.id)
+		return true
+	})
 
-			for j := k; j >= 0; j-- {
-				results = append(results, i.values[j])
+	return results
+}
+
+func (i *SortedIndex) collect(lower *Bound, upper *Bound) []int64 {
+	var results []int64
+
+	i.Between(lower, upper, func(_ dbtype.DBType, id int64) bool {
+		results = append(results, id)
+		return true
+	})
+
+	return results
+}
+
+func (n *node) find(e entry) (int, bool) {
+	k := sort.Search(len(n.entries), func(k int) bool {
+		return less(e, n.entries[k])
+	})
+
+	if k > 0 && !less(n.entries[k-1], e) {
+		return k - 1, true
+	}
+
+	return k, false
+}
+
+func (n *node) split(k int) (entry, *node) {
+	middle := n.entries[k]
+
+	right := &node{
+		entries: append([]entry{}, n.entries[k+1:]...),
+	}
+
+	n.entries = append([]entry{}, n.entries[:k]...)
+
+	if len(n.children) > 0 {
+		right.children = append([]*node{}, n.children[k+1:]...)
+		n.children = append([]*node{}, n.children[:k+1]...)
+	}
+
+	return middle, right
+}
+
+func (n *node) insert(e entry) bool {
+	k, found := n.find(e)
+
+	if found {
+		return false
+	}
+
+	if len(n.children) == 0 {
+		n.entries = insertEntry(n.entries, k, e)
+		return true
+	}
+
+	if len(n.children[k].entries) >= maxEntries {
+		middle, right := n.children[k].split(maxEntries / 2)
+
+		n.entries = insertEntry(n.entries, k, middle)
+		n.children = insertChild(n.children, k+1, right)
+
+		if less(middle, e) {
+			k++
+		} else if !less(e, middle) {
+			return false
+		}
+	}
+
+	return n.children[k].insert(e)
+}
+
+func (n *node) remove(e entry) bool {
+	k, found := n.find(e)
+
+	if len(n.children) == 0 {
+		if !found {
+			return false
+		}
+
+		n.entries = append(n.entries[:k], n.entries[k+1:]...)
+		return true
+	}
+
+	//make sure the child we descend into can give up an entry
+	if len(n.children[k].entries) <= minEntries {
+		n.growChild(k)
+		return n.remove(e)
+	}
+
+	if found {
+		n.entries[k] = n.children[k].removeMax()
+		return true
+	}
+
+	return n.children[k].remove(e)
+}
+
+func (n *node) removeMax() entry {
+	if len(n.children) == 0 {
+		last := n.entries[len(n.entries)-1]
+		n.entries = n.entries[:len(n.entries)-1]
+		return last
+	}
+
+	k := len(n.entries)
+
+	if len(n.children[k].entries) <= minEntries {
+		n.growChild(k)
+		return n.removeMax()
+	}
+
+	return n.children[k].removeMax()
+}
+
+func (n *node) growChild(k int) {
+	if k > 0 && len(n.children[k-1].entries) > minEntries {
+		//borrow from the left sibling
+		child := n.children[k]
+		left := n.children[k-1]
+
+		child.entries = insertEntry(child.entries, 0, n.entries[k-1])
+		n.entries[k-1] = left.entries[len(left.entries)-1]
+		left.entries = left.entries[:len(left.entries)-1]
+
+		if len(left.children) > 0 {
+			child.children = insertChild(child.children, 0, left.children[len(left.children)-1])
+			left.children = left.children[:len(left.children)-1]
+		}
+	} else if k < len(n.entries) && len(n.children[k+1].entries) > minEntries {
+		//borrow from the right sibling
+		child := n.children[k]
+		right := n.children[k+1]
+
+		child.entries = append(child.entries, n.entries[k])
+		n.entries[k] = right.entries[0]
+		right.entries = append([]entry{}, right.entries[1:]...)
+
+		if len(right.children) > 0 {
+			child.children = append(child.children, right.children[0])
+			right.children = append([]*node{}, right.children[1:]...)
+		}
+	} else {
+		//merge with a sibling
+		if k >= len(n.entries) {
+			k--
+		}
+
+		child := n.children[k]
+		right := n.children[k+1]
+
+		child.entries = append(child.entries, n.entries[k])
+		child.entries = append(child.entries, right.entries...)
+		child.children = append(child.children, right.children...)
+
+		n.entries = append(n.entries[:k], n.entries[k+1:]...)
+		n.children = append(n.children[:k+1], n.children[k+2:]...)
+	}
+}
+
+func (n *node) ascend(start *entry, inclusive bool, iterator func(e entry) bool) bool {
+	k := 0
+
+	if start != nil {
+		k = sort.Search(len(n.entries), func(k int) bool {
+			return !less(n.entries[k], *start)
+		})
+	}
+
+	for ; k < len(n.entries); k++ {
+		if len(n.children) > 0 && !n.children[k].ascend(start, inclusive, iterator) {
+			return false
+		}
+
+		if start != nil && !inclusive && !less(*start, n.entries[k]) {
+			continue
+		}
+
+		if !iterator(n.entries[k]) {
+			return false
+		}
+	}
+
+	if len(n.children) > 0 {
+		return n.children[len(n.children)-1].ascend(start, inclusive, iterator)
+	}
+
+	return true
+}
+
+func (n *node) descend(start *entry, inclusive bool, iterator func(e entry) bool) bool {
+	k := len(n.entries)
+
+	if start != nil {
+		k = sort.Search(len(n.entries), func(k int) bool {
+			return less(*start, n.entries[k])
+		})
+	}
+
+	if len(n.children) > 0 && !n.children[k].descend(start, inclusive, iterator) {
+		return false
+	}
+
+	for k--; k >= 0; k-- {
+		if start == nil || inclusive || less(n.entries[k], *start) {
+			if !iterator(n.entries[k]) {
+				return false
 			}
+		}
 
-			return results
+		if len(n.children) > 0 && !n.children[k].descend(start, inclusive, iterator) {
+			return false
 		}
 	}
 
-	return nil
+	return true
+}
+
+func insertEntry(entries []entry, k int, e entry) []entry {
+	entries = append(entries, entry{})
+	copy(entries[k+1:], entries[k:])
+	entries[k] = e
+
+	return entries
+}
+
+func insertChild(children []*node, k int, child *node) []*node {
+	children = append(children, nil)
+	copy(children[k+1:], children[k:])
+	children[k] = child
+
+	return children
+}
+
+func less(a entry, b entry) bool {
+	c := compareValues(a.value, b.value)
+
+	if c != 0 {
+		return c < 0
+	}
+
+	return a.id < b.id
+}
+
+func compareValues(a dbtype.DBType, b dbtype.DBType) int {
+	aNull, bNull := isNull(a), isNull(b)
+
+	switch {
+	case aNull && bNull:
+		return 0
+	case aNull:
+		return 1
+	case bNull:
+		return -1
+	case a.Smaller(b):
+		return -1
+	case a.Larger(b):
+		return 1
+	}
+
+	return 0
+}
+
+func isNull(value dbtype.DBType) bool {
+	return value == nil || value.IsNull()
 }
@@ -0,0 +1,210 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package index
+
+import (
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSortedIndexAddRemove(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	i := NewSortedIndex()
+	values := map[int64]int64{}
+
+	for k := 0; k < 20000; k++ {
+		id := r.Int63n(5000)
+
+		if v, ok := values[id]; ok && r.Intn(3) > 0 {
+			i.Remove(number(t, v), id)
+			delete(values, id)
+			continue
+		}
+
+		if v, ok := values[id]; ok {
+			i.Remove(number(t, v), id)
+		}
+
+		v := r.Int63n(500)
+		i.Add(number(t, v), id)
+		values[id] = v
+	}
+
+	expected := sortedIds(values)
+
+	if i.Len() != len(expected) {
+		t.Fatalf("expected %d entries, index has %d", len(expected), i.Len())
+	}
+
+	var ascending []int64
+
+	i.Ascend(func(_ dbtype.DBType, id int64) bool {
+		ascending = append(ascending, id)
+		return true
+	})
+
+	assertIds(t, expected, ascending)
+
+	var descending []int64
+
+	i.Descend(func(_ dbtype.DBType, id int64) bool {
+		descending = append([]int64{id}, descending...)
+		return true
+	})
+
+	assertIds(t, expected, descending)
+}
+
+func TestSortedIndexBetween(t *testing.T) {
+	i := NewSortedIndex()
+
+	for id := int64(0); id < 1000; id++ {
+		i.Add(number(t, id%100), id)
+	}
+
+	i.Add(dbtype.NumberFromNull(), 1000)
+
+	cases := []struct {
+		lower *Bound
+		upper *Bound
+		count int
+	}{
+		{lower: &Bound{Value: number(t, 10)}, upper: &Bound{Value: number(t, 20)}, count: 90},
+		{lower: &Bound{Value: number(t, 10), Inclusive: true}, upper: &Bound{Value: number(t, 20)}, count: 100},
+		{lower: &Bound{Value: number(t, 10)}, upper: &Bound{Value: number(t, 20), Inclusive: true}, count: 100},
+		{lower: &Bound{Value: number(t, 10), Inclusive: true}, upper: &Bound{Value: number(t, 20), Inclusive: true}, count: 110},
+		{lower: nil, upper: &Bound{Value: number(t, 5)}, count: 50},
+		{lower: &Bound{Value: number(t, 94)}, upper: nil, count: 50},
+		{lower: nil, upper: nil, count: 1000},
+	}
+
+	for _, tc := range cases {
+		var last dbtype.DBType
+		count := 0
+
+		i.Between(tc.lower, tc.upper, func(value dbtype.DBType, _ int64) bool {
+			if last != nil && value.Smaller(last) {
+				t.Errorf("values are not in ascending order")
+			}
+
+			last = value
+			count++
+
+			return true
+		})
+
+		if count != tc.count {
+			t.Errorf("expected %d entries, got %d", tc.count, count)
+		}
+	}
+
+	all := func(int64) bool {
+		return true
+	}
+
+	if min, _, ok := i.Min(all); !ok || !min.Equal(number(t, 0)) {
+		t.Errorf("expected min 0")
+	}
+
+	if max, _, ok := i.Max(all); !ok || !max.Equal(number(t, 99)) {
+		t.Errorf("expected max 99")
+	}
+
+	if _, id, ok := i.Max(func(id int64) bool { return id < 50 }); !ok || id != 49 {
+		t.Errorf("expected max of filtered entries to be id 49, got %d", id)
+	}
+}
+
+func TestSortedIndexAfterAndBefore(t *testing.T) {
+	i := NewSortedIndex()
+
+	for id := int64(0); id < 100; id++ {
+		i.Add(number(t, id%10), id)
+	}
+
+	i.Add(dbtype.NumberFromNull(), 100)
+
+	var ascending []int64
+
+	i.AscendAfter(number(t, 4), 34, func(_ dbtype.DBType, id int64) bool {
+		ascending = append(ascending, id)
+		return len(ascending) < 3
+	})
+
+	assertIds(t, []int64{44, 54, 64}, ascending)
+
+	var descending []int64
+
+	i.DescendBefore(number(t, 4), 34, func(_ dbtype.DBType, id int64) bool {
+		descending = append(descending, id)
+		return len(descending) < 3
+	})
+
+	assertIds(t, []int64{24, 14, 4}, descending)
+
+	count := 0
+
+	i.AscendAfter(nil, 0, func(value dbtype.DBType, _ int64) bool {
+		count++
+		return true
+	})
+
+	if count != 100 {
+		t.Errorf("expected 100 non-null entries in ascending order, got %d", count)
+	}
+
+	var first int64 = -1
+
+	i.DescendBefore(nil, 0, func(_ dbtype.DBType, id int64) bool {
+		first = id
+		return false
+	})
+
+	if first != 99 {
+		t.Errorf("expected descending order to start at id 99, got %d", first)
+	}
+}
+
+func number(t *testing.T, i int64) dbtype.DBType {
+	n, err := dbtype.NumberFromInt64(i)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func sortedIds(values map[int64]int64) []int64 {
+	var ids []int64
+
+	for id := range values {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(a, b int) bool {
+		if values[ids[a]] != values[ids[b]] {
+			return values[ids[a]] < values[ids[b]]
+		}
+
+		return ids[a] < ids[b]
+	})
+
+	return ids
+}
+
+func assertIds(t *testing.T, expected []int64, actual []int64) {
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %d ids, got %d", len(expected), len(actual))
+	}
+
+	for k := range expected {
+		if expected[k] != actual[k] {
+			t.Fatalf("expected id %d at position %d, got %d", expected[k], k, actual[k])
+		}
+	}
+}
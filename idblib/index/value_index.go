@@ -68,6 +68,21 @@ func (i *ValueIndex) GetValue(id int64) dbtype.DBType {
 	return nil
 }
 
+func (i *ValueIndex) Values() map[int64]dbtype.DBType {
+	i.RLock()
+	defer i.RUnlock()
+
+	values := map[int64]dbtype.DBType{}
+
+	for k := 0; k < len(i.values); k++ {
+		for id, value := range i.values[k] {
+			values[id] = value
+		}
+	}
+
+	return values
+}
+
 func (i *ValueIndex) Range(compare func(compareValue dbtype.DBType) bool) []int64 {
 	i.RLock()
 	defer i.RUnlock()
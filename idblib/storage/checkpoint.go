@@ -0,0 +1,276 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	idblib "github.com/lucasl0st/InfiniteDB/idblib/object"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const checkpointFileName = "index.idb"
+const checkpointInterval = time.Minute * 5
+
+type checkpoint struct {
+	//number of lines in objects.idb covered by this checkpoint
+	Lines        int64  `json:"lines"`
+	LastLineHash string `json:"lastLineHash"`
+
+	Fields          map[string]field.Field `json:"fields"`
+	NextId          int64                  `json:"nextId"`
+	NumberOfObjects int64                  `json:"numberOfObjects"`
+	Locations       map[int64]int64        `json:"locations"`
+
+	//fieldName -> objectId -> value
+	Indexes map[string]map[int64]string `json:"indexes"`
+}
+
+func (s *Storage) Checkpoint() error {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	s.checkpointLock.Lock()
+	defer s.checkpointLock.Unlock()
+
+	var c checkpoint
+
+	s.swapLock.RLock()
+
+	err := s.file.Checkpoint(func(lines int64, lastLine string) {
+		s.locationsLock.RLock()
+
+		locations := make(map[int64]int64, len(s.locations))
+
+		for id, lineNumber := range s.locations {
+			locations[id] = lineNumber
+		}
+
+		c = checkpoint{
+			Lines:           lines,
+			LastLineHash:    hashLine(lastLine),
+			Fields:          s.fields,
+			NextId:          s.nextId,
+			NumberOfObjects: s.NumberOfObjects,
+			Locations:       locations,
+		}
+
+		s.locationsLock.RUnlock()
+
+		c.Indexes = s.indexValues()
+	})
+
+	s.swapLock.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(c)
+
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.checkpointPath), checkpointFileName+".*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.WriteString(hashLine(string(bytes)) + "\n" + string(bytes))
+
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	err = tmp.Close()
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), s.checkpointPath)
+
+	if err != nil {
+		return err
+	}
+
+	s.checkpointLines = c.Lines
+
+	return nil
+}
+
+func (s *Storage) SetFields(fields map[string]field.Field) {
+	s.checkpointLock.Lock()
+	defer s.checkpointLock.Unlock()
+
+	s.fields = fields
+}
+
+func (s *Storage) loadCheckpoint() int64 {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	c, err := s.readCheckpoint()
+
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			s.logger.Println("rebuilding indexes of " + s.checkpointPath + ": " + err.Error())
+		}
+
+		return 0
+	}
+
+	err = s.restoreCheckpoint(c)
+
+	if err != nil {
+		s.logger.Println("rebuilding indexes of " + s.checkpointPath + ": " + err.Error())
+		s.replacedFile()
+
+		return 0
+	}
+
+	s.checkpointLines = c.Lines
+
+	return c.Lines
+}
+
+func (s *Storage) readCheckpoint() (*checkpoint, error) {
+	bytes, err := os.ReadFile(s.checkpointPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(string(bytes), "\n", 2)
+
+	if len(parts) != 2 || hashLine(parts[1]) != parts[0] {
+		return nil, e.IndexCheckpointIsCorrupted()
+	}
+
+	var c checkpoint
+
+	err = json.Unmarshal([]byte(parts[1]), &c)
+
+	if err != nil {
+		return nil, e.IndexCheckpointIsCorrupted()
+	}
+
+	if !reflect.DeepEqual(c.Fields, s.fields) {
+		return nil, e.IndexCheckpointIsStale()
+	}
+
+	if c.Lines > 0 {
+		lines, err := s.file.Read([]int64{c.Lines - 1})
+
+		if err != nil {
+			return nil, err
+		}
+
+		//the file was compacted or replaced since the checkpoint was written
+		if line, ok := lines[c.Lines-1]; !ok || hashLine(line) != c.LastLineHash {
+			return nil, e.IndexCheckpointIsStale()
+		}
+	}
+
+	return &c, nil
+}
+
+func (s *Storage) restoreCheckpoint(c *checkpoint) error {
+	if c.Locations == nil {
+		c.Locations = map[int64]int64{}
+	}
+
+	s.locationsLock.Lock()
+	s.locations = c.Locations
+	s.nextId = c.NextId
+	s.locationsLock.Unlock()
+
+	s.NumberOfObjects = c.NumberOfObjects
+
+	for id := range c.Locations {
+		o := idblib.Object{
+			Id: id,
+			M:  map[string]dbtype.DBType{},
+		}
+
+		for fieldName, values := range c.Indexes {
+			str, ok := values[id]
+
+			if !ok {
+				continue
+			}
+
+			f, ok := s.fields[fieldName]
+
+			if !ok {
+				return e.IndexCheckpointIsStale()
+			}
+
+			v, err := idbutil.StringToDBType(str, f)
+
+			if err != nil {
+				return err
+			}
+
+			o.M[fieldName] = v
+		}
+
+		s.metricAddTotalObject()
+		s.addedObject(o)
+	}
+
+	return nil
+}
+
+func (s *Storage) runCheckpoints() {
+	ticker := time.NewTicker(checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCheckpoints:
+			return
+		case <-ticker.C:
+			s.checkpointLock.Lock()
+			changed := s.file.NumberOfLines() != s.checkpointLines
+			s.checkpointLock.Unlock()
+
+			if !changed {
+				continue
+			}
+
+			err := s.Checkpoint()
+
+			if err != nil {
+				s.logger.Println("failed to write index checkpoint " + s.checkpointPath + ": " + err.Error())
+			}
+		}
+	}
+}
+
+func hashLine(line string) string {
+	sum := sha256.Sum256([]byte(line))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package storage
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"os"
+	"strings"
+	"testing"
+)
+
+func (a *added) get(id int64) map[string]string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.objects[id]
+}
+
+func TestCheckpointIsLoaded(t *testing.T) {
+	path := t.TempDir() + "/"
+
+	s, _ := newTestStorage(t, path, testFields)
+
+	ids := write(t, s, s.AddEvent(object("a", "1")), s.AddEvent(object("b", "2")))
+
+	err := s.Checkpoint()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//written after the checkpoint, read from the file when loading
+	ids = append(ids, write(t, s, s.AddEvent(object("c", "3")))...)
+
+	s.Kill()
+
+	reopened, a := newTestStorage(t, path, testFields)
+
+	//only the values of the indexes are stored in the checkpoint
+	for k, id := range ids[:2] {
+		if m := a.get(id); len(m) != 1 || m["name"] != []string{"a", "b"}[k] {
+			t.Errorf("expected object %d to be restored from the checkpoint, got %v", id, m)
+		}
+	}
+
+	if m := a.get(ids[2]); m["value"] != "3" {
+		t.Errorf("expected object %d to be read from the file, got %v", ids[2], m)
+	}
+
+	assertObjects(t, map[int64]map[string]string{
+		ids[0]: {"name": "a", "value": "1"},
+		ids[1]: {"name": "b", "value": "2"},
+		ids[2]: {"name": "c", "value": "3"},
+	}, read(t, reopened))
+}
+
+func TestStaleCheckpointIsReplayed(t *testing.T) {
+	fields := map[string]field.Field{
+		"extra": {Name: "extra", Type: dbtype.TEXT, Null: true},
+	}
+
+	for name, f := range testFields {
+		fields[name] = f
+	}
+
+	cases := []struct {
+		name   string
+		change func(t *testing.T, path string)
+		fields map[string]field.Field
+		last   string
+	}{
+		{name: "changed last line", change: changeLastLine, fields: testFields, last: "changed"},
+		{name: "changed fields", change: func(t *testing.T, path string) {}, fields: fields, last: "c"},
+		{name: "corrupted", change: corruptCheckpoint, fields: testFields, last: "c"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := t.TempDir() + "/"
+
+			s, _ := newTestStorage(t, path, testFields)
+
+			ids := write(t, s, s.AddEvent(object("a", "1")), s.AddEvent(object("b", "2")), s.AddEvent(object("c", "3")))
+
+			s.Close()
+
+			tc.change(t, path)
+
+			reopened, a := newTestStorage(t, path, tc.fields)
+
+			for _, id := range ids {
+				if m := a.get(id); m["value"] == "" {
+					t.Errorf("expected object %d to be read from the file, got %v", id, m)
+				}
+			}
+
+			assertObjects(t, map[int64]map[string]string{
+				ids[0]: {"name": "a", "value": "1"},
+				ids[1]: {"name": "b", "value": "2"},
+				ids[2]: {"name": tc.last, "value": "3"},
+			}, read(t, reopened))
+		})
+	}
+}
+
+// changeLastLine changes the name of the object in the last line of the file without changing the number of lines
+func changeLastLine(t *testing.T, path string) {
+	bytes, err := os.ReadFile(path + objectsFileName)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(bytes), "\n"), "\n")
+
+	var event Event
+
+	err = json.Unmarshal([]byte(lines[len(lines)-1]), &event)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event.Data["name"] = "changed"
+
+	changed, err := json.Marshal(event)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines[len(lines)-1] = string(changed)
+
+	err = os.WriteFile(path+objectsFileName, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func corruptCheckpoint(t *testing.T, path string) {
+	err := os.WriteFile(path+checkpointFileName, []byte("corrupted"), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -6,6 +6,7 @@ package storage
 
 type Event struct {
 	Type     EventType         `json:"type"`
+	Id       *int64            `json:"id,omitempty"`
 	Data     map[string]string `json:"data,omitempty"`
 	RefersTo *int64            `json:"refersTo,omitempty"`
 }
@@ -16,4 +17,16 @@ const (
 	EventTypeAdd    EventType = "ADD"
 	EventTypeUpdate EventType = "UPDATE"
 	EventTypeRemove EventType = "REMOVE"
+
+	//marks ids up to Id as used, written at the start of compacted files
+	EventTypeSequence EventType = "SEQUENCE"
 )
+
+func (e Event) objectId(lineNumber int64) int64 {
+	//objects written before ids were persisted use the line number of their event as id
+	if e.Id != nil {
+		return *e.Id
+	}
+
+	return lineNumber
+}
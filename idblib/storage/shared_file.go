@@ -9,14 +9,28 @@ import (
 	"github.com/lucasl0st/InfiniteDB/idblib/file"
 	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
 	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"os"
+	"path/filepath"
+	"sync"
 )
 
 type SharedFile struct {
+	path string
 	file *file.File
 	*file.Lock
 
+	//the file lock is shared by all goroutines of this process
+	writeLock sync.Mutex
+	//guarded by writeLock, nothing is written after the file was killed
+	killed bool
+
+	readLock  sync.Mutex
 	readLines int64
+	info      os.FileInfo
+
 	addedLine func(lineNumber int64, line string)
+	replaced  func()
 
 	watcher *fsnotify.Watcher
 	watch   bool
@@ -24,39 +38,57 @@ type SharedFile struct {
 	logger idbutil.Logger
 }
 
-func New(path string, addedLine func(lineNumber int64, line string), logger idbutil.Logger) (*SharedFile, error) {
+func New(path string, addedLine func(lineNumber int64, line string), replaced func(), logger idbutil.Logger) (*SharedFile, error) {
 	f, err := file.New(path)
 
 	if err != nil {
 		return nil, err
 	}
 
+	info, err := f.Stat()
+
+	if err != nil {
+		return nil, err
+	}
+
 	watcher, err := fsnotify.NewWatcher()
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = watcher.Add(path)
+	//watch the directory, the file itself gets swapped out when compacting
+	err = watcher.Add(filepath.Dir(path))
 
 	if err != nil {
 		return nil, err
 	}
 
 	s := &SharedFile{
+		path:      filepath.Clean(path),
 		file:      f,
 		Lock:      file.NewLock(path + ".lock"),
 		readLines: 0,
+		info:      info,
 		addedLine: addedLine,
+		replaced:  replaced,
 		watcher:   watcher,
 		watch:     true,
 		logger:    logger,
 	}
 
-	err = s.readChanges()
+	return s, nil
+}
+
+func (s *SharedFile) Open(readLines int64) error {
+	s.readLock.Lock()
+	s.readLines = readLines
+	s.readLock.Unlock()
+
+	err := s.readChanges()
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	go func() {
@@ -65,13 +97,20 @@ func New(path string, addedLine func(lineNumber int64, line string), logger idbu
 		}
 	}()
 
-	return s, nil
+	return nil
 }
 
-func (s *SharedFile) Write(events []Event, getLine func(event Event, lineNumber int64) string) error {
+func (s *SharedFile) Write(prepare func() ([]Event, error), getLine func(event Event, lineNumber int64) string) error {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
 
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	if s.killed {
+		return e.TableWasClosed()
+	}
+
 	err := s.Lock.Lock()
 
 	if err != nil {
@@ -82,10 +121,23 @@ func (s *SharedFile) Write(events []Event, getLine func(event Event, lineNumber
 		err = s.Lock.Unlock()
 	}()
 
+	//catch up with changes of other processes, otherwise line numbers would be wrong
+	err = s.readChanges()
+
+	if err != nil {
+		return err
+	}
+
+	events, err := prepare()
+
+	if err != nil {
+		return err
+	}
+
 	var lines []string
 
-	for _, o := range events {
-		line := getLine(o, s.readLines)
+	for i, o := range events {
+		line := getLine(o, s.readLines+int64(i))
 		lines = append(lines, line)
 	}
 
@@ -104,6 +156,73 @@ func (s *SharedFile) Write(events []Event, getLine func(event Event, lineNumber
 	return err
 }
 
+func (s *SharedFile) Exclusive(f func() error) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	if s.killed {
+		return e.TableWasClosed()
+	}
+
+	err := s.Lock.Lock()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = s.Lock.Unlock()
+	}()
+
+	err = s.readChanges()
+
+	if err != nil {
+		return err
+	}
+
+	return f()
+}
+
+func (s *SharedFile) Rewrite(build func() ([]string, error), swap func(replace func() error) error) error {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	if s.killed {
+		return e.TableWasClosed()
+	}
+
+	err := s.Lock.Lock()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = s.Lock.Unlock()
+	}()
+
+	err = s.readChanges()
+
+	if err != nil {
+		return err
+	}
+
+	lines, err := build()
+
+	if err != nil {
+		return err
+	}
+
+	err = swap(func() error {
+		return s.replace(lines)
+	})
+
+	return err
+}
+
 func (s *SharedFile) Read(lineNumbers []int64) (map[int64]string, error) {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
@@ -111,20 +230,108 @@ func (s *SharedFile) Read(lineNumbers []int64) (map[int64]string, error) {
 	return s.file.Read(lineNumbers)
 }
 
+func (s *SharedFile) NumberOfLines() int64 {
+	s.readLock.Lock()
+	defer s.readLock.Unlock()
+
+	return s.readLines
+}
+
+func (s *SharedFile) Checkpoint(snapshot func(lines int64, lastLine string)) error {
+	s.readLock.Lock()
+	defer s.readLock.Unlock()
+
+	var lastLine string
+
+	if s.readLines > 0 {
+		lines, err := s.file.Read([]int64{s.readLines - 1})
+
+		if err != nil {
+			return err
+		}
+
+		lastLine = lines[s.readLines-1]
+	}
+
+	snapshot(s.readLines, lastLine)
+
+	return nil
+}
+
 func (s *SharedFile) readChanges() error {
-	err := s.file.ReadAtStartLine(s.readLines, s.addedLine)
+	s.readLock.Lock()
+	defer s.readLock.Unlock()
+
+	replaced, err := s.replacedOnDisk()
+
+	if err != nil {
+		return err
+	}
+
+	if replaced {
+		err = s.reload()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.file.ReadAtStartLine(s.readLines, func(lineNumber int64, line string) {
+		s.addedLine(lineNumber, line)
+		s.readLines = lineNumber + 1
+	})
+}
+
+func (s *SharedFile) replace(lines []string) error {
+	s.readLock.Lock()
+	defer s.readLock.Unlock()
+
+	err := s.file.Replace(lines)
 
 	if err != nil {
 		return err
 	}
 
-	lines, err := s.file.NumberOfLines()
+	info, err := s.file.Stat()
 
 	if err != nil {
 		return err
 	}
 
-	s.readLines = int64(lines)
+	//this process already knows the contents, other processes reload the file
+	s.info = info
+	s.readLines = int64(len(lines))
+
+	return nil
+}
+
+func (s *SharedFile) replacedOnDisk() (bool, error) {
+	info, err := s.file.Stat()
+
+	if err != nil {
+		return false, err
+	}
+
+	return !os.SameFile(s.info, info), nil
+}
+
+func (s *SharedFile) reload() error {
+	info, err := s.file.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	err = s.file.Reset()
+
+	if err != nil {
+		return err
+	}
+
+	s.info = info
+	s.readLines = 0
+	s.replaced()
+
 	return nil
 }
 
@@ -135,6 +342,10 @@ func (s *SharedFile) watchChanges() {
 			return
 		}
 
+		if filepath.Clean(event.Name) != s.path {
+			return
+		}
+
 		if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
 			if !s.HaveLock {
 				err := s.readChanges()
@@ -147,6 +358,22 @@ func (s *SharedFile) watchChanges() {
 	}
 }
 
+// Kill stops watching the file, it waits for running writes and rejects all following ones
 func (s *SharedFile) Kill() {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	if s.killed {
+		return
+	}
+
+	s.killed = true
 	s.watch = false
+
+	//tables are killed whenever they are reloaded, every open watcher uses up an inotify instance
+	err := s.watcher.Close()
+
+	if err != nil {
+		s.logger.Println("failed to stop watching " + s.path + ": " + err.Error())
+	}
 }
@@ -7,12 +7,16 @@ package storage
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/lucasl0st/InfiniteDB/idblib/cache"
 	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
 	"github.com/lucasl0st/InfiniteDB/idblib/field"
 	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
 	idblib "github.com/lucasl0st/InfiniteDB/idblib/object"
 	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"sort"
+	"sync"
 )
 
 const objectsFileName = "objects.idb"
@@ -24,7 +28,22 @@ type Storage struct {
 	fields map[string]field.Field
 
 	addedObject   func(object idblib.Object)
-	deletedObject func(object idblib.Object)
+	deletedObject func(id int64)
+	reset         func()
+	indexValues   func() map[string]map[int64]string
+
+	//objectId -> line number of its latest event
+	locationsLock sync.RWMutex
+	locations     map[int64]int64
+	nextId        int64
+
+	swapLock sync.RWMutex
+
+	checkpointPath  string
+	checkpointLock  sync.Mutex
+	checkpointLines int64
+	stopCheckpoints chan bool
+	stopOnce        sync.Once
 
 	NumberOfObjects int64
 
@@ -38,7 +57,9 @@ func NewStorage(
 	path string,
 	fields map[string]field.Field,
 	addedObject func(object idblib.Object),
-	deletedObject func(object idblib.Object),
+	deletedObject func(id int64),
+	reset func(),
+	indexValues func() map[string]map[int64]string,
 	cacheSize uint,
 	logger idbutil.Logger,
 	metricAddTotalObject func(),
@@ -49,12 +70,17 @@ func NewStorage(
 		fields:               fields,
 		addedObject:          addedObject,
 		deletedObject:        deletedObject,
+		reset:                reset,
+		indexValues:          indexValues,
+		locations:            map[int64]int64{},
+		checkpointPath:       path + checkpointFileName,
+		stopCheckpoints:      make(chan bool),
 		logger:               logger,
 		metricAddTotalObject: metricAddTotalObject,
 		metricWroteObject:    metricWroteObject,
 	}
 
-	file, err := New(path+objectsFileName, s.addedLineInFile, logger)
+	file, err := New(path+objectsFileName, s.addedLineInFile, s.replacedFile, logger)
 
 	if err != nil {
 		return nil, err
@@ -62,13 +88,35 @@ func NewStorage(
 
 	s.file = file
 
+	err = s.file.Open(s.loadCheckpoint())
+
+	if err != nil {
+		return nil, err
+	}
+
+	go s.runCheckpoints()
+
 	return s, nil
 }
 
 func (s *Storage) Kill() {
+	s.stopOnce.Do(func() {
+		close(s.stopCheckpoints)
+	})
+
 	s.file.Kill()
 }
 
+func (s *Storage) Close() {
+	err := s.Checkpoint()
+
+	if err != nil {
+		s.logger.Println("failed to write index checkpoint " + s.checkpointPath + ": " + err.Error())
+	}
+
+	s.Kill()
+}
+
 func (s *Storage) addedLineInFile(lineNumber int64, line string) {
 	var event Event
 
@@ -78,17 +126,25 @@ func (s *Storage) addedLineInFile(lineNumber int64, line string) {
 		s.logger.Fatal(err.Error())
 	}
 
-	if event.Type == EventTypeRemove {
-		o := s.GetObject(*event.RefersTo)
-		s.deletedObject(*o)
-
-		s.NumberOfObjects--
+	if event.Type == EventTypeSequence {
+		s.reserveId(*event.Id)
 		return
 	}
 
-	if event.Type == EventTypeUpdate {
-		o := s.GetObject(*event.RefersTo)
-		s.deletedObject(*o)
+	if event.Type == EventTypeRemove || event.Type == EventTypeUpdate {
+		s.c.Remove(*event.RefersTo)
+
+		if s.removeLocation(*event.RefersTo) {
+			s.deletedObject(*event.RefersTo)
+
+			if event.Type == EventTypeRemove {
+				s.NumberOfObjects--
+			}
+		}
+
+		if event.Type == EventTypeRemove {
+			return
+		}
 	}
 
 	if event.Type == EventTypeAdd {
@@ -96,31 +152,252 @@ func (s *Storage) addedLineInFile(lineNumber int64, line string) {
 		s.metricAddTotalObject()
 	}
 
-	s.addedObject(s.eventToObject(lineNumber, event))
+	id := event.objectId(lineNumber)
+
+	s.reserveId(id)
+	s.setLocation(id, lineNumber)
+	s.addedObject(s.eventToObject(id, event))
+}
+
+func (s *Storage) replacedFile() {
+	s.c.Clear()
+
+	s.locationsLock.Lock()
+	s.locations = map[int64]int64{}
+	s.locationsLock.Unlock()
+
+	s.NumberOfObjects = 0
+	s.reset()
+}
+
+func (s *Storage) reserveId(id int64) {
+	s.locationsLock.Lock()
+	defer s.locationsLock.Unlock()
+
+	if id >= s.nextId {
+		s.nextId = id + 1
+	}
+}
+
+func (s *Storage) setLocation(id int64, lineNumber int64) {
+	s.locationsLock.Lock()
+	defer s.locationsLock.Unlock()
+
+	s.locations[id] = lineNumber
+}
+
+func (s *Storage) removeLocation(id int64) bool {
+	s.locationsLock.Lock()
+	defer s.locationsLock.Unlock()
+
+	_, ok := s.locations[id]
+	delete(s.locations, id)
+
+	return ok
+}
+
+func (s *Storage) Ids() []int64 {
+	s.locationsLock.RLock()
+	defer s.locationsLock.RUnlock()
+
+	ids := make([]int64, 0, len(s.locations))
+
+	for id := range s.locations {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	return ids
+}
+
+func (s *Storage) Compact() (int64, int64, error) {
+	return s.CompactWith(func() (map[string]field.Field, error) {
+		return s.fields, nil
+	})
 }
 
-func (s *Storage) GetObject(id int64) *idblib.Object {
+// CompactWith rewrites the objects through the fields returned by prepare, prepare is called while holding the lock of the file
+func (s *Storage) CompactWith(prepare func() (map[string]field.Field, error)) (int64, int64, error) {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
 
-	objects := s.GetObjects([]int64{id})
+	var before int64
+	var after int64
+
+	locations := map[int64]int64{}
+
+	err := s.file.Rewrite(func() ([]string, error) {
+		fields, err := prepare()
+
+		if err != nil {
+			return nil, err
+		}
+
+		before = s.file.NumberOfLines()
+
+		var compacted []string
+
+		s.locationsLock.RLock()
+		lastId := s.nextId - 1
+		s.locationsLock.RUnlock()
+
+		if lastId >= 0 {
+			bytes, err := json.Marshal(Event{
+				Type: EventTypeSequence,
+				Id:   &lastId,
+			})
+
+			if err != nil {
+				return nil, err
+			}
+
+			compacted = append(compacted, string(bytes))
+		}
+
+		s.locationsLock.RLock()
+
+		ids := make([]int64, 0, len(s.locations))
+		current := make(map[int64]int64, len(s.locations))
+		var lineNumbers []int64
+
+		for id, lineNumber := range s.locations {
+			ids = append(ids, id)
+			current[id] = lineNumber
+			lineNumbers = append(lineNumbers, lineNumber)
+		}
+
+		s.locationsLock.RUnlock()
+
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+
+		lines, err := s.file.Read(lineNumbers)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			var event Event
+
+			err = json.Unmarshal([]byte(lines[current[id]]), &event)
+
+			if err != nil {
+				return nil, err
+			}
+
+			objectId := id
+
+			//written through the fields, drops removed fields and stores defaults and conversions
+			bytes, err := json.Marshal(s.mapStringDbTypeToEvent(s.eventToObjectWith(id, event, fields).M, EventTypeAdd, &objectId, nil))
+
+			if err != nil {
+				return nil, err
+			}
+
+			locations[id] = int64(len(compacted))
+			compacted = append(compacted, string(bytes))
+		}
+
+		after = int64(len(compacted))
+
+		return compacted, nil
+	}, func(replace func() error) error {
+		s.swapLock.Lock()
+		defer s.swapLock.Unlock()
+
+		err := replace()
+
+		if err != nil {
+			return err
+		}
+
+		s.locationsLock.Lock()
+		s.locations = locations
+		s.locationsLock.Unlock()
+
+		return nil
+	})
+
+	if err != nil {
+		return before, after, err
+	}
+
+	//the previous checkpoint refers to lines of the old file
+	err = s.Checkpoint()
+
+	if err != nil {
+		s.logger.Println("failed to write index checkpoint " + s.checkpointPath + ": " + err.Error())
+	}
+
+	return before, after, nil
+}
+
+func (s *Storage) Exclusive(f func() error) error {
+	return s.file.Exclusive(f)
+}
+
+func (s *Storage) GetObject(id int64) (*idblib.Object, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	objects, err := s.GetObjects([]int64{id})
+
+	if err != nil {
+		return nil, err
+	}
 
 	if len(objects) > 1 {
 		s.logger.Fatal(errors.New("too many results"))
 	} else if len(objects) == 1 {
-		return &objects[0]
+		return &objects[0], nil
 	}
 
-	return nil
+	return nil, nil
 }
 
-func (s *Storage) GetObjects(ids []int64) []idblib.Object {
+func (s *Storage) GetObjects(ids []int64) ([]idblib.Object, error) {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
 
+	read, err := s.getObjects(ids, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[int64]idblib.Object{}
+
+	for _, o := range read {
+		found[o.Id] = o
+	}
+
+	//keep the order of ids, it is the sort order of the query
+	objects := make([]idblib.Object, 0, len(found))
+
+	for _, id := range ids {
+		if o, ok := found[id]; ok {
+			objects = append(objects, o)
+		}
+	}
+
+	return objects, nil
+}
+
+func (s *Storage) getObjects(ids []int64, retry bool) ([]idblib.Object, error) {
 	var objects []idblib.Object
 
-	var notCached []int64
+	s.swapLock.RLock()
+
+	//line number -> objectId
+	notCached := map[int64]int64{}
+	var lineNumbers []int64
+
+	s.locationsLock.RLock()
 
 	for _, id := range ids {
 		cached := s.c.Get(id)
@@ -130,18 +407,34 @@ func (s *Storage) GetObjects(ids []int64) []idblib.Object {
 				Id: id,
 				M:  *cached,
 			})
-		} else {
-			notCached = append(notCached, id)
+		} else if lineNumber, ok := s.locations[id]; ok {
+			notCached[lineNumber] = id
+			lineNumbers = append(lineNumbers, lineNumber)
 		}
 	}
 
-	lines, err := s.file.Read(notCached)
+	s.locationsLock.RUnlock()
+
+	lines, err := s.file.Read(lineNumbers)
+
+	s.swapLock.RUnlock()
 
 	if err != nil {
 		s.logger.Fatal(err.Error())
 	}
 
-	for lineNumber, line := range lines {
+	var moved []int64
+
+	for lineNumber, id := range notCached {
+		line, ok := lines[lineNumber]
+
+		//the file was compacted by another process and this one did not reload it yet,
+		//the line is past the end of the shorter file or holds another object now
+		if !ok {
+			moved = append(moved, id)
+			continue
+		}
+
 		var event Event
 
 		err = json.Unmarshal([]byte(line), &event)
@@ -150,45 +443,85 @@ func (s *Storage) GetObjects(ids []int64) []idblib.Object {
 			s.logger.Fatal(err.Error())
 		}
 
-		o := s.eventToObject(lineNumber, event)
+		if event.objectId(lineNumber) != id {
+			moved = append(moved, id)
+			continue
+		}
+
+		o := s.eventToObject(id, event)
 
 		objects = append(objects, o)
 
 		s.c.Set(o)
 	}
 
-	return objects
+	if len(moved) == 0 {
+		return objects, nil
+	}
+
+	//the locations were already read again, the objects cannot be found in the file
+	if !retry {
+		return nil, e.ObjectCannotBeRead(moved[0])
+	}
+
+	err = s.file.readChanges()
+
+	if err != nil {
+		return nil, err
+	}
+
+	reread, err := s.getObjects(moved, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(objects, reread...), nil
 }
 
-func (s *Storage) AddObject(m map[string]dbtype.DBType) {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
+func (s *Storage) Exists(id int64) bool {
+	s.locationsLock.RLock()
+	defer s.locationsLock.RUnlock()
 
-	s.writeEvents([]Event{
-		s.mapStringDbTypeToEvent(m, EventTypeAdd, nil),
-	})
+	_, ok := s.locations[id]
+
+	return ok
 }
 
-func (s *Storage) UpdateObject(o idblib.Object) {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
+func (s *Storage) AddEvent(m map[string]dbtype.DBType) Event {
+	return s.mapStringDbTypeToEvent(m, EventTypeAdd, nil, nil)
+}
 
-	s.writeEvents([]Event{
-		s.mapStringDbTypeToEvent(o.M, EventTypeUpdate, &o.Id),
-	})
+func (s *Storage) UpdateEvent(o idblib.Object) Event {
+	return s.mapStringDbTypeToEvent(o.M, EventTypeUpdate, &o.Id, &o.Id)
 }
 
-func (s *Storage) RemoveObject(o idblib.Object) {
+func (s *Storage) RemoveEvent(o idblib.Object) Event {
+	return s.mapStringDbTypeToEvent(nil, EventTypeRemove, nil, &o.Id)
+}
+
+func (s *Storage) Write(prepare func() ([]Event, error)) ([]int64, error) {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
 
-	s.writeEvents([]Event{
-		s.mapStringDbTypeToEvent(nil, EventTypeRemove, &o.Id),
-	})
-}
+	var ids []int64
+
+	err := s.file.Write(prepare, func(event Event, lineNumber int64) string {
+		if event.Type == EventTypeAdd && event.Id == nil {
+			s.locationsLock.Lock()
+			id := s.nextId
+			s.nextId++
+			s.locationsLock.Unlock()
+
+			event.Id = &id
+		}
+
+		if event.Id != nil {
+			ids = append(ids, *event.Id)
+		} else {
+			ids = append(ids, *event.RefersTo)
+		}
 
-func (s *Storage) writeEvents(events []Event) {
-	err := s.file.Write(events, func(event Event, lineNumber int64) string {
 		bytes, err := json.Marshal(event)
 
 		if err != nil {
@@ -197,38 +530,48 @@ func (s *Storage) writeEvents(events []Event) {
 
 		s.metricWroteObject()
 
-		//invalidate cache
-		s.c.Remove(lineNumber)
-
-		if event.RefersTo != nil {
-			s.c.Remove(*event.RefersTo)
-		}
-
 		return string(bytes)
 	})
 
 	if err != nil {
-		s.logger.Fatal(err.Error())
+		return nil, err
 	}
+
+	return ids, nil
+}
+
+func (s *Storage) eventToObject(id int64, event Event) idblib.Object {
+	return s.eventToObjectWith(id, event, s.fields)
 }
 
-func (s *Storage) eventToObject(eventId int64, event Event) idblib.Object {
+func (s *Storage) eventToObjectWith(id int64, event Event, fields map[string]field.Field) idblib.Object {
 	o := idblib.Object{
-		Id: eventId,
+		Id: id,
 		M:  map[string]dbtype.DBType{},
 	}
 
-	for _, f := range s.fields {
+	for _, f := range fields {
 		str, ok := event.Data[f.Name]
 
 		if !ok {
-			continue
+			if f.Default == nil {
+				continue
+			}
+
+			str = *f.Default
 		}
 
 		v, err := idbutil.StringToDBType(str, f)
 
+		//the field was converted to a type this value cannot be read as
 		if err != nil {
-			s.logger.Fatal(err.Error())
+			s.logger.Println("cannot read value of field " + f.Name + " of object " + fmt.Sprint(id) + ": " + err.Error())
+
+			v, err = idbutil.NullDBType(f)
+
+			if err != nil {
+				s.logger.Fatal(err.Error())
+			}
 		}
 
 		o.M[f.Name] = v
@@ -237,9 +580,10 @@ func (s *Storage) eventToObject(eventId int64, event Event) idblib.Object {
 	return o
 }
 
-func (s *Storage) mapStringDbTypeToEvent(m map[string]dbtype.DBType, eventType EventType, refersTo *int64) Event {
+func (s *Storage) mapStringDbTypeToEvent(m map[string]dbtype.DBType, eventType EventType, id *int64, refersTo *int64) Event {
 	event := Event{
 		Type:     eventType,
+		Id:       id,
 		Data:     map[string]string{},
 		RefersTo: refersTo,
 	}
@@ -0,0 +1,255 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package storage
+
+import (
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	idblib "github.com/lucasl0st/InfiniteDB/idblib/object"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+var testFields = map[string]field.Field{
+	"name":  {Name: "name", Type: dbtype.TEXT},
+	"value": {Name: "value", Type: dbtype.TEXT},
+}
+
+// added records the objects the storage reported, like the indexes of a table
+type added struct {
+	lock    sync.Mutex
+	objects map[int64]map[string]string
+}
+
+func (a *added) add(o idblib.Object) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	m := map[string]string{}
+
+	for fieldName, value := range o.M {
+		m[fieldName] = value.ToString()
+	}
+
+	a.objects[o.Id] = m
+}
+
+func (a *added) remove(id int64) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	delete(a.objects, id)
+}
+
+func (a *added) reset() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.objects = map[int64]map[string]string{}
+}
+
+// indexValues returns the names of the objects, the storage writes them to its checkpoints
+func (a *added) indexValues() map[string]map[int64]string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	names := map[int64]string{}
+
+	for id, m := range a.objects {
+		names[id] = m["name"]
+	}
+
+	return map[string]map[int64]string{"name": names}
+}
+
+func newTestStorage(t *testing.T, path string, fields map[string]field.Field) (*Storage, *added) {
+	a := &added{objects: map[int64]map[string]string{}}
+
+	s, err := NewStorage(
+		path,
+		fields,
+		a.add,
+		a.remove,
+		a.reset,
+		a.indexValues,
+		100,
+		log.New(io.Discard, "", 0),
+		func() {},
+		func() {},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(s.Kill)
+
+	return s, a
+}
+
+func object(name string, value string) map[string]dbtype.DBType {
+	return map[string]dbtype.DBType{
+		"name":  dbtype.TextFromString(name),
+		"value": dbtype.TextFromString(value),
+	}
+}
+
+func write(t *testing.T, s *Storage, events ...Event) []int64 {
+	ids, err := s.Write(func() ([]Event, error) {
+		return events, nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ids
+}
+
+// read returns all objects of the storage with their values as strings
+func read(t *testing.T, s *Storage) map[int64]map[string]string {
+	objects, err := s.GetObjects(s.Ids())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := map[int64]map[string]string{}
+
+	for _, o := range objects {
+		results[o.Id] = map[string]string{}
+
+		for fieldName, value := range o.M {
+			results[o.Id][fieldName] = value.ToString()
+		}
+	}
+
+	return results
+}
+
+func assertObjects(t *testing.T, expected map[int64]map[string]string, objects map[int64]map[string]string) {
+	t.Helper()
+
+	if !reflect.DeepEqual(expected, objects) {
+		t.Errorf("expected %v, got %v", expected, objects)
+	}
+}
+
+func TestCompactKeepsObjects(t *testing.T) {
+	path := t.TempDir() + "/"
+
+	s, _ := newTestStorage(t, path, testFields)
+
+	ids := write(t, s, s.AddEvent(object("a", "1")), s.AddEvent(object("b", "2")), s.AddEvent(object("c", "3")))
+	write(t, s, s.UpdateEvent(idblib.Object{Id: ids[1], M: object("b", "4")}))
+	write(t, s, s.RemoveEvent(idblib.Object{Id: ids[0]}))
+
+	expected := map[int64]map[string]string{
+		ids[1]: {"name": "b", "value": "4"},
+		ids[2]: {"name": "c", "value": "3"},
+	}
+
+	assertObjects(t, expected, read(t, s))
+
+	before, after, err := s.Compact()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the sequence of ids and one line per object
+	if before != 5 || after != 3 {
+		t.Errorf("expected 5 lines to be compacted to 3, got %d and %d", before, after)
+	}
+
+	assertObjects(t, expected, read(t, s))
+
+	s.Kill()
+
+	reopened, _ := newTestStorage(t, path, testFields)
+
+	assertObjects(t, expected, read(t, reopened))
+}
+
+func TestCompactionIsSeenByOtherStorage(t *testing.T) {
+	path := t.TempDir() + "/"
+
+	s, _ := newTestStorage(t, path, testFields)
+
+	ids := write(t, s, s.AddEvent(object("a", "1")), s.AddEvent(object("b", "2")), s.AddEvent(object("c", "3")))
+	write(t, s, s.UpdateEvent(idblib.Object{Id: ids[0], M: object("a", "4")}))
+	write(t, s, s.RemoveEvent(idblib.Object{Id: ids[1]}))
+
+	other, _ := newTestStorage(t, path, testFields)
+
+	expected := map[int64]map[string]string{
+		ids[0]: {"name": "a", "value": "4"},
+		ids[2]: {"name": "c", "value": "3"},
+	}
+
+	_, _, err := s.Compact()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the lines of the objects moved, the other storage has to read the compacted file
+	assertObjects(t, expected, read(t, other))
+
+	added := write(t, other, other.AddEvent(object("d", "5")))
+
+	if added[0] <= ids[2] {
+		t.Errorf("expected an id larger than %d, got %d", ids[2], added[0])
+	}
+}
+
+func TestIdsAreStable(t *testing.T) {
+	path := t.TempDir() + "/"
+
+	s, _ := newTestStorage(t, path, testFields)
+
+	ids := write(t, s, s.AddEvent(object("a", "1")), s.AddEvent(object("b", "2")), s.AddEvent(object("c", "3")))
+
+	updated := write(t, s, s.UpdateEvent(idblib.Object{Id: ids[0], M: object("a", "4")}))
+
+	if updated[0] != ids[0] {
+		t.Errorf("expected the update to keep id %d, got %d", ids[0], updated[0])
+	}
+
+	//the largest id is not used by any object after compacting
+	write(t, s, s.RemoveEvent(idblib.Object{Id: ids[2]}))
+
+	_, _, err := s.Compact()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Kill()
+
+	//read the compacted file again instead of the checkpoint
+	err = os.Remove(path + checkpointFileName)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, _ := newTestStorage(t, path, testFields)
+
+	assertObjects(t, map[int64]map[string]string{
+		ids[0]: {"name": "a", "value": "4"},
+		ids[1]: {"name": "b", "value": "2"},
+	}, read(t, reopened))
+
+	added := write(t, reopened, reopened.AddEvent(object("d", "5")))
+
+	if added[0] <= ids[2] {
+		t.Errorf("expected an id larger than the removed %d, got %d", ids[2], added[0])
+	}
+}
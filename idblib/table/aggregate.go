@@ -0,0 +1,282 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// additional decimal places of averages compared to the values they are computed from
+const avgScale = 16
+
+type Row map[string]dbtype.DBType
+
+type group struct {
+	values []dbtype.DBType
+	states []*accumulatorState
+}
+
+type accumulatorState struct {
+	count    int64
+	sum      *big.Rat
+	scale    int
+	extreme  dbtype.DBType
+	distinct map[string]bool
+}
+
+// Aggregate groups the objects by the values of a.GroupBy and returns one row per group
+func (t *Table) Aggregate(objects object.Objects, a request.Aggregate) ([]Row, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	fields := map[string]field.Field{}
+
+	for _, fieldName := range a.GroupBy {
+		f, ok := t.Config.Fields[fieldName]
+
+		if !ok {
+			return nil, e.CannotFindField(fieldName)
+		}
+
+		fields[fieldName] = f
+	}
+
+	for _, accumulator := range a.Accumulators {
+		if len(accumulator.Field) == 0 {
+			continue
+		}
+
+		f, ok := t.Config.Fields[accumulator.Field]
+
+		if !ok {
+			return nil, e.CannotFindField(accumulator.Field)
+		}
+
+		if (accumulator.Accumulator == request.SUM || accumulator.Accumulator == request.AVG) && f.Type != dbtype.NUMBER {
+			return nil, e.AccumulatorNeedsNumberField(string(accumulator.Accumulator), accumulator.Field)
+		}
+
+		fields[accumulator.Field] = f
+	}
+
+	var fieldNames []string
+
+	for fieldName := range fields {
+		fieldNames = append(fieldNames, fieldName)
+	}
+
+	values, indexed := t.IndexedObjects(objects, fieldNames)
+
+	if !indexed {
+		var err error
+
+		values, err = t.Storage.GetObjects(objects)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groups := map[string]*group{}
+	var order []*group
+
+	for _, o := range values {
+		var key strings.Builder
+		groupValues := make([]dbtype.DBType, len(a.GroupBy))
+
+		for k, fieldName := range a.GroupBy {
+			value := o.M[fieldName]
+
+			if value == nil || value.IsNull() {
+				key.WriteString("\x00")
+			} else {
+				key.WriteString("\x01" + value.ToString() + "\x00")
+				groupValues[k] = value
+			}
+		}
+
+		g, ok := groups[key.String()]
+
+		if !ok {
+			g = newGroup(groupValues, len(a.Accumulators))
+			groups[key.String()] = g
+			order = append(order, g)
+		}
+
+		for k, accumulator := range a.Accumulators {
+			g.states[k].add(accumulator, o.M[accumulator.Field])
+		}
+	}
+
+	//without groups there is always one row, even if no object matched
+	if len(a.GroupBy) == 0 && len(order) == 0 {
+		order = append(order, newGroup(nil, len(a.Accumulators)))
+	}
+
+	var rows []Row
+
+	for _, g := range order {
+		row := Row{}
+
+		for k, fieldName := range a.GroupBy {
+			value := g.values[k]
+
+			if value == nil {
+				null, err := idbutil.NullDBType(fields[fieldName])
+
+				if err != nil {
+					return nil, err
+				}
+
+				value = null
+			}
+
+			row[fieldName] = value
+		}
+
+		for k, accumulator := range a.Accumulators {
+			value, err := g.states[k].result(accumulator, fields[accumulator.Field])
+
+			if err != nil {
+				return nil, err
+			}
+
+			row[accumulator.As] = value
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func SortRows(rows []Row, keys request.SortKeys) ([]Row, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	if len(rows) > 0 {
+		for _, key := range keys {
+			if _, ok := rows[0][key.Field]; !ok {
+				return nil, e.CannotFindField(key.Field)
+			}
+		}
+	}
+
+	sort.SliceStable(rows, func(a, b int) bool {
+		for _, key := range keys {
+			if c := compareSortValues(key, rows[a][key.Field], rows[b][key.Field]); c != 0 {
+				return c < 0
+			}
+		}
+
+		return false
+	})
+
+	return rows, nil
+}
+
+func SkipAndLimitRows(rows []Row, skip *int64, limit *int64) []Row {
+	start, end := int64(0), int64(len(rows))
+
+	if skip != nil && *skip < end {
+		start = *skip
+	} else if skip != nil {
+		start = end
+	}
+
+	if limit != nil && start+*limit < end {
+		end = start + *limit
+	}
+
+	return rows[start:end]
+}
+
+func newGroup(values []dbtype.DBType, accumulators int) *group {
+	g := &group{
+		values: values,
+		states: make([]*accumulatorState, accumulators),
+	}
+
+	for k := range g.states {
+		g.states[k] = &accumulatorState{
+			sum:      new(big.Rat),
+			distinct: map[string]bool{},
+		}
+	}
+
+	return g
+}
+
+func (s *accumulatorState) add(accumulator request.Accumulator, value dbtype.DBType) {
+	//null values are not accumulated, count without a field counts every object
+	if value == nil || value.IsNull() {
+		if accumulator.Accumulator == request.COUNT && len(accumulator.Field) == 0 {
+			s.count++
+		}
+
+		return
+	}
+
+	s.count++
+
+	switch accumulator.Accumulator {
+	case request.SUM, request.AVG:
+		n := value.(dbtype.Number)
+
+		s.sum.Add(s.sum, n.ToRat())
+
+		if n.Scale() > s.scale {
+			s.scale = n.Scale()
+		}
+	case request.MIN:
+		if s.extreme == nil || value.Smaller(s.extreme) {
+			s.extreme = value
+		}
+	case request.MAX:
+		if s.extreme == nil || value.Larger(s.extreme) {
+			s.extreme = value
+		}
+	case request.COUNT_DISTINCT:
+		s.distinct[value.ToString()] = true
+	}
+}
+
+func (s *accumulatorState) result(accumulator request.Accumulator, f field.Field) (dbtype.DBType, error) {
+	switch accumulator.Accumulator {
+	case request.COUNT:
+		return dbtype.NumberFromInt64(s.count)
+	case request.COUNT_DISTINCT:
+		return dbtype.NumberFromInt64(int64(len(s.distinct)))
+	case request.SUM:
+		if s.count == 0 {
+			return dbtype.NumberFromNull(), nil
+		}
+
+		return dbtype.NumberFromRat(s.sum, s.scale)
+	case request.AVG:
+		if s.count == 0 {
+			return dbtype.NumberFromNull(), nil
+		}
+
+		avg := new(big.Rat).Quo(s.sum, new(big.Rat).SetInt64(s.count))
+
+		return dbtype.NumberFromRat(avg, s.scale+avgScale)
+	}
+
+	if s.extreme == nil {
+		return idbutil.NullDBType(f)
+	}
+
+	return s.extreme, nil
+}
@@ -0,0 +1,295 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+)
+
+const alterTableBatchSize = 1000
+
+type AlterTableChange struct {
+	Action    request.AlterTableAction
+	FieldName string
+	Type      *dbtype.DatabaseType
+	Indexed   *bool
+	Unique    *bool
+	Null      *bool
+	Default   json.RawMessage
+}
+
+func (t *Table) Alter(changes []AlterTableChange) (field.TableConfig, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	//only validates and writes table.json, the table has to be loaded again to use the new config
+
+	var config field.TableConfig
+
+	//computed while holding the lock of the table file, another process may alter the table at the same time
+	alter := func() error {
+		var changed map[string]bool
+		var err error
+
+		config, changed, err = t.alteredConfig(changes)
+
+		if err != nil {
+			return err
+		}
+
+		err = t.validateAlteredFields(config.Fields, changed)
+
+		if err != nil {
+			return err
+		}
+
+		return t.writeConfig(config)
+	}
+
+	var err error
+
+	//dropped fields are removed from the objects and converted values are stored as their new type,
+	//added fields are read with their default and do not need the objects to be written again
+	if rewritesObjects(changes) {
+		_, _, err = t.Storage.CompactWith(func() (map[string]field.Field, error) {
+			err := alter()
+
+			if err != nil {
+				return nil, err
+			}
+
+			return config.Fields, nil
+		})
+	} else {
+		err = t.Storage.Exclusive(alter)
+	}
+
+	if err != nil {
+		return field.TableConfig{}, err
+	}
+
+	return config, nil
+}
+
+func rewritesObjects(changes []AlterTableChange) bool {
+	for _, c := range changes {
+		if c.Action == request.DROP_FIELD || (c.Action == request.ALTER_FIELD && c.Type != nil) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *Table) alteredConfig(changes []AlterTableChange) (field.TableConfig, map[string]bool, error) {
+	fields := make(map[string]field.Field, len(t.Config.Fields))
+
+	for fieldName, f := range t.Config.Fields {
+		fields[fieldName] = f
+	}
+
+	changed := map[string]bool{}
+
+	for _, c := range changes {
+		if changed[c.FieldName] {
+			return field.TableConfig{}, nil, e.FieldIsChangedMoreThanOnce(c.FieldName)
+		}
+
+		changed[c.FieldName] = true
+
+		f, exists := fields[c.FieldName]
+
+		switch c.Action {
+		case request.ADD_FIELD:
+			if exists {
+				return field.TableConfig{}, nil, e.FieldAlreadyExists(c.FieldName)
+			}
+
+			if c.Type == nil {
+				return field.TableConfig{}, nil, e.TypeNotSupported("")
+			}
+
+			f = field.Field{
+				Name: c.FieldName,
+				Type: *c.Type,
+			}
+		case request.DROP_FIELD:
+			if !exists || c.FieldName == field.InternalObjectIdField {
+				return field.TableConfig{}, nil, e.CannotFindField(c.FieldName)
+			}
+
+			if t.isCombinedUnique(c.FieldName) {
+				return field.TableConfig{}, nil, e.FieldIsPartOfCombinedUnique(c.FieldName)
+			}
+
+			delete(fields, c.FieldName)
+			continue
+		case request.ALTER_FIELD:
+			if !exists || c.FieldName == field.InternalObjectIdField {
+				return field.TableConfig{}, nil, e.CannotFindField(c.FieldName)
+			}
+
+			if c.Type != nil && *c.Type != f.Type {
+				if !canConvert(f.Type, *c.Type) {
+					return field.TableConfig{}, nil, e.CannotConvertField(
+						c.FieldName,
+						dbtype.DatabaseTypeToString(f.Type),
+						dbtype.DatabaseTypeToString(*c.Type),
+					)
+				}
+
+				f.Type = *c.Type
+			}
+		default:
+			return field.TableConfig{}, nil, e.NotAValidAlterTableAction(string(c.Action))
+		}
+
+		if c.Indexed != nil {
+			f.Indexed = *c.Indexed
+		}
+
+		if c.Unique != nil {
+			f.Unique = *c.Unique
+		}
+
+		if c.Null != nil {
+			f.Null = *c.Null
+		}
+
+		if f.Unique && !f.Indexed {
+			return field.TableConfig{}, nil, e.FieldCannotBeUniqueWithoutBeingIndexed()
+		}
+
+		if !f.Indexed && t.isCombinedUnique(c.FieldName) {
+			return field.TableConfig{}, nil, e.FieldIsPartOfCombinedUnique(c.FieldName)
+		}
+
+		if c.Default != nil {
+			v, err := idbutil.JsonRawToDBType(c.Default, f)
+
+			if err != nil {
+				return field.TableConfig{}, nil, err
+			}
+
+			f.Default = nil
+
+			if !v.IsNull() {
+				str := v.ToString()
+				f.Default = &str
+			}
+		} else if f.Default != nil {
+			//the default has to be readable as the new type as well
+			_, err := idbutil.StringToDBType(*f.Default, f)
+
+			if err != nil {
+				return field.TableConfig{}, nil, e.CannotConvertValueOfField(c.FieldName, *f.Default)
+			}
+		}
+
+		fields[c.FieldName] = f
+	}
+
+	return field.TableConfig{
+		Fields:      fields,
+		Options:     t.Config.Options,
+		IndexBuilds: pendingIndexBuilds(t.Config.IndexBuilds, fields),
+	}, changed, nil
+}
+
+func (t *Table) validateAlteredFields(fields map[string]field.Field, changed map[string]bool) error {
+	//fieldName -> values of unique fields
+	uniques := map[string]map[string]bool{}
+
+	ids := t.Storage.Ids()
+
+	for start := 0; start < len(ids); start += alterTableBatchSize {
+		end := start + alterTableBatchSize
+
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		objects, err := t.Storage.GetObjects(ids[start:end])
+
+		if err != nil {
+			return err
+		}
+
+		for _, o := range objects {
+			for fieldName := range changed {
+				f, ok := fields[fieldName]
+
+				if !ok {
+					continue
+				}
+
+				v, err := alteredValue(o, f)
+
+				if err != nil {
+					return err
+				}
+
+				if v == nil || v.IsNull() {
+					if !f.Null {
+						return e.ObjectDoesNotHaveValueForField(fieldName)
+					}
+
+					continue
+				}
+
+				if !f.Unique {
+					continue
+				}
+
+				if uniques[fieldName] == nil {
+					uniques[fieldName] = map[string]bool{}
+				}
+
+				if uniques[fieldName][v.ToString()] {
+					return e.FoundExistingObjectWithField(fieldName)
+				}
+
+				uniques[fieldName][v.ToString()] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+func alteredValue(o object.Object, f field.Field) (dbtype.DBType, error) {
+	v, ok := o.M[f.Name]
+
+	if !ok {
+		if f.Default == nil {
+			return nil, nil
+		}
+
+		return idbutil.StringToDBType(*f.Default, f)
+	}
+
+	if v.IsNull() {
+		return idbutil.NullDBType(f)
+	}
+
+	converted, err := idbutil.StringToDBType(v.ToString(), f)
+
+	if err != nil {
+		return nil, e.CannotConvertValueOfField(f.Name, v.ToString())
+	}
+
+	return converted, nil
+}
+
+func canConvert(from dbtype.DatabaseType, to dbtype.DatabaseType) bool {
+	return (from == dbtype.NUMBER && to == dbtype.TEXT) || (from == dbtype.TEXT && to == dbtype.NUMBER)
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"os"
+	"path/filepath"
+)
+
+const configFileName = "table.json"
+
+func (t *Table) StoredConfig() field.TableConfig {
+	return storedConfig(t.Config)
+}
+
+func storedConfig(config field.TableConfig) field.TableConfig {
+	stored := field.TableConfig{
+		Fields:      map[string]field.Field{},
+		Options:     config.Options,
+		IndexBuilds: config.IndexBuilds,
+	}
+
+	for fieldName, f := range config.Fields {
+		if fieldName != field.InternalObjectIdField {
+			stored.Fields[fieldName] = f
+		}
+	}
+
+	return stored
+}
+
+func (t *Table) saveConfig() error {
+	return t.writeConfig(t.Config)
+}
+
+func (t *Table) writeConfig(config field.TableConfig) error {
+	bytes, err := json.Marshal(storedConfig(config))
+
+	if err != nil {
+		return err
+	}
+
+	path := t.path + t.Name + "/" + configFileName
+
+	//other processes watch table.json, they must never read a partially written file
+	tmp, err := os.CreateTemp(filepath.Dir(path), configFileName+".*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(bytes)
+
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	err = tmp.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
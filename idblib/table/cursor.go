@@ -0,0 +1,325 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+	"sort"
+)
+
+// Cursor is the position in the sort order after which a page continues
+type Cursor struct {
+	Keys request.SortKeys `json:"keys"`
+	//values of the sort keys of the last object, null if the object had no value
+	Values []json.RawMessage `json:"values,omitempty"`
+	//nil if no page was read yet
+	Id *int64 `json:"id,omitempty"`
+}
+
+// NewCursor returns a cursor before the first object in the order of s, no sort orders by object id
+func NewCursor(s request.SortKeys) Cursor {
+	if len(s) == 0 {
+		s = request.SortKeys{{Field: field.InternalObjectIdField}}
+	}
+
+	keys := make(request.SortKeys, 0, len(s))
+
+	for _, key := range s {
+		if key.Direction != request.DESC {
+			key.Direction = request.ASC
+		}
+
+		if key.Nulls != request.NULLS_FIRST {
+			key.Nulls = request.NULLS_LAST
+		}
+
+		keys = append(keys, key)
+	}
+
+	return Cursor{
+		Keys: keys,
+	}
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return Cursor{}, e.NotAValidCursor()
+	}
+
+	var c Cursor
+	err = json.Unmarshal(bytes, &c)
+
+	if err != nil || len(c.Keys) == 0 || (c.Id != nil && len(c.Values) != len(c.Keys)) {
+		return Cursor{}, e.NotAValidCursor()
+	}
+
+	return c, nil
+}
+
+func (c Cursor) Encode() string {
+	bytes, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+// Matches returns if the cursor continues the order of s
+func (c Cursor) Matches(s request.SortKeys) bool {
+	other := NewCursor(s)
+
+	if len(c.Keys) != len(other.Keys) {
+		return false
+	}
+
+	for k, key := range c.Keys {
+		if key != other.Keys[k] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Page returns at most limit objects of o following cursor and the cursor to continue after them, which is nil if there are no more objects
+func (t *Table) Page(o object.Objects, cursor Cursor, additionalFields AdditionalFields, limit *int64) (object.Objects, *Cursor, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	if len(o) == 0 {
+		return o, nil, nil
+	}
+
+	keys, err := t.sortKeys(cursor.Keys, o, additionalFields)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var after *sortEntry
+
+	if cursor.Id != nil {
+		after, err = t.cursorEntry(cursor, o, additionalFields)
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	//one more than the limit is read to know if there is a next page
+	full := func(entries []sortEntry) bool {
+		return limit != nil && int64(len(entries)) > *limit
+	}
+
+	var entries []sortEntry
+
+	if len(keys) == 1 && keys[0].index != nil && !hasAdditionalValues(o, keys[0].Field, additionalFields) {
+		entries = t.pageByIndex(o, keys[0], after, full)
+	} else {
+		entries = t.pageByValues(o, keys, after, additionalFields, limit)
+	}
+
+	more := full(entries)
+
+	if more {
+		entries = entries[:*limit]
+	}
+
+	if !more {
+		return entryIds(entries), nil, nil
+	}
+
+	next := Cursor{
+		Keys:   cursor.Keys,
+		Id:     cursor.Id,
+		Values: cursor.Values,
+	}
+
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		next.Id = &last.id
+		next.Values = make([]json.RawMessage, 0, len(last.values))
+
+		for _, value := range last.values {
+			if isNull(value) {
+				next.Values = append(next.Values, nil)
+			} else {
+				next.Values = append(next.Values, value.ToJsonRaw())
+			}
+		}
+	}
+
+	return entryIds(entries), &next, nil
+}
+
+// pageByIndex walks the index from the cursor on and keeps the requested objects, the objects with null values are added before or after them
+func (t *Table) pageByIndex(o object.Objects, key sortKey, after *sortEntry, full func(entries []sortEntry) bool) []sortEntry {
+	var entries []sortEntry
+
+	//the cursor is within the objects with null values
+	inNulls := after != nil && isNull(after.values[0])
+
+	values := func() {
+		wanted := make(map[int64]bool, len(o))
+
+		for _, id := range o {
+			wanted[id] = true
+		}
+
+		var value dbtype.DBType
+		var id int64
+
+		if after != nil && !inNulls {
+			value = after.values[0]
+			id = after.id
+		}
+
+		collect := func(value dbtype.DBType, id int64) bool {
+			if wanted[id] {
+				entries = append(entries, sortEntry{id: id, values: []dbtype.DBType{value}})
+				delete(wanted, id)
+			}
+
+			return len(wanted) > 0 && !full(entries)
+		}
+
+		if key.Direction == request.DESC {
+			key.index.DescendBefore(value, id, collect)
+		} else {
+			key.index.AscendAfter(value, id, collect)
+		}
+	}
+
+	nulls := func() {
+		var ids []int64
+
+		for _, id := range o {
+			if !isNull(key.index.GetValue(id)) {
+				continue
+			}
+
+			if inNulls && (id == after.id || (id < after.id) == (key.Direction != request.DESC)) {
+				continue
+			}
+
+			ids = append(ids, id)
+		}
+
+		sortIds(ids, key.Direction)
+
+		for _, id := range ids {
+			if full(entries) {
+				break
+			}
+
+			entries = append(entries, sortEntry{id: id, values: []dbtype.DBType{nil}})
+		}
+	}
+
+	if key.Nulls == request.NULLS_FIRST {
+		if after == nil || inNulls {
+			nulls()
+		}
+
+		if !full(entries) {
+			values()
+		}
+	} else {
+		if !inNulls {
+			values()
+		}
+
+		if !full(entries) {
+			nulls()
+		}
+	}
+
+	return entries
+}
+
+// pageByValues sorts the objects following the cursor if the order needs more than one index or the values of functions, which are not indexed
+func (t *Table) pageByValues(o object.Objects, keys []sortKey, after *sortEntry, additionalFields AdditionalFields, limit *int64) []sortEntry {
+	var entries []sortEntry
+
+	for _, entry := range t.sortEntries(o, keys, additionalFields) {
+		if after == nil || compareEntries(keys, *after, entry) < 0 {
+			entries = append(entries, entry)
+		}
+	}
+
+	if limit != nil {
+		return topK(entries, keys, int(*limit+1))
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		return compareEntries(keys, entries[a], entries[b]) < 0
+	})
+
+	return entries
+}
+
+// cursorEntry reads the values of the cursor with the types of their fields, values of functions and implemented tables with the type of the values of the objects
+func (t *Table) cursorEntry(cursor Cursor, o object.Objects, additionalFields AdditionalFields) (*sortEntry, error) {
+	if len(cursor.Values) != len(cursor.Keys) {
+		return nil, e.NotAValidCursor()
+	}
+
+	entry := sortEntry{
+		id:     *cursor.Id,
+		values: make([]dbtype.DBType, len(cursor.Keys)),
+	}
+
+	for k, key := range cursor.Keys {
+		raw := cursor.Values[k]
+
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		f, ok := t.Config.Fields[key.Field]
+
+		if !ok {
+			f = field.Field{Name: key.Field, Type: additionalType(o, key.Field, additionalFields)}
+		}
+
+		value, err := idbutil.JsonRawToDBType(raw, f)
+
+		if err != nil {
+			return nil, e.NotAValidCursor()
+		}
+
+		entry.values[k] = value
+	}
+
+	return &entry, nil
+}
+
+// additionalType returns the type of the additional values of a field, number if the objects have none
+func additionalType(o object.Objects, fieldName string, additionalFields AdditionalFields) dbtype.DatabaseType {
+	for _, id := range o {
+		switch additionalFields[id][fieldName].(type) {
+		case dbtype.Text:
+			return dbtype.TEXT
+		case dbtype.Bool:
+			return dbtype.BOOL
+		case dbtype.Number:
+			return dbtype.NUMBER
+		}
+	}
+
+	return dbtype.NUMBER
+}
+
+func isNull(value dbtype.DBType) bool {
+	return value == nil || value.IsNull()
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+	"time"
+)
+
+const (
+	RequestStage      = "request"
+	QueryStage        = "query"
+	WhereStage        = "where"
+	AndStage          = "and"
+	OrStage           = "or"
+	NotStage          = "not"
+	FunctionStage     = "function"
+	SortStage         = "sort"
+	SkipAndLimitStage = "skipAndLimit"
+	PageStage         = "page"
+	ImplementStage    = "implement"
+	ReadStage         = "read"
+	AggregateStage    = "aggregate"
+)
+
+const (
+	//the objects matching the where are read from the index
+	IndexLookupAccess = "indexLookup"
+	//the objects matching the where are read from the index and intersected with the candidates
+	IndexIntersectAccess = "indexIntersect"
+	//the value of every candidate is read from the index and compared
+	IndexFilterAccess = "indexFilter"
+	//the field is not indexed, every candidate is read from storage and compared
+	ScanAccess = "scan"
+	//the returned fields are read from the indexes
+	IndexReadAccess = "index"
+	//the returned objects are read from storage
+	StorageReadAccess = "storage"
+)
+
+// ExplainStage describes how one step of a request was evaluated, a nil stage records nothing
+type ExplainStage struct {
+	Stage    string
+	Field    string
+	Operator request.Operator
+	Value    json.RawMessage
+	Index    string
+	Access   string
+	Function string
+	Estimate *int
+	Before   int
+	After    int
+	Duration time.Duration
+	Stages   []*ExplainStage
+
+	start time.Time
+}
+
+func NewExplainStage(stage string) *ExplainStage {
+	return &ExplainStage{
+		Stage: stage,
+		start: time.Now(),
+	}
+}
+
+// Add starts child as a sub stage of s
+func (s *ExplainStage) Add(child *ExplainStage) *ExplainStage {
+	if s == nil {
+		return nil
+	}
+
+	s.Stages = append(s.Stages, child)
+
+	return child
+}
+
+// Finish records the number of objects going into and coming out of the stage and how long it took
+func (s *ExplainStage) Finish(before int, after int) {
+	if s == nil {
+		return
+	}
+
+	s.Before = before
+	s.After = after
+	s.Duration = time.Since(s.start)
+}
+
+func (s *ExplainStage) setEstimate(estimate int) {
+	if s != nil {
+		s.Estimate = &estimate
+	}
+}
+
+// SetAccess records how the objects of the stage were found
+func (s *ExplainStage) SetAccess(access string, index string) {
+	if s != nil {
+		s.Access = access
+		s.Index = index
+	}
+}
+
+// finish records the stage with nil objects counting as all objects of the table
+func (t *Table) finish(s *ExplainStage, before object.Objects, after object.Objects) {
+	if s == nil {
+		return
+	}
+
+	s.Finish(t.candidates(before), t.candidates(after))
+}
+
+func (t *Table) candidates(objects object.Objects) int {
+	if objects == nil {
+		return t.Size()
+	}
+
+	return len(objects)
+}
+
+func whereStage(w request.Where) *ExplainStage {
+	s := NewExplainStage(WhereStage)
+	s.Field = w.Field
+	s.Operator = w.Operator
+	s.Value = w.Value
+
+	return s
+}
@@ -0,0 +1,360 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/index"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+)
+
+const indexBuildBatchSize = 1000
+
+type indexBuild struct {
+	index     *index.Index
+	processed int64
+	total     int64
+}
+
+type IndexBuildProgress struct {
+	Processed int64
+	Total     int64
+}
+
+func (t *Table) CreateIndex(fieldName string) (IndexBuildProgress, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	if _, ok := t.Config.Fields[fieldName]; !ok {
+		return IndexBuildProgress{}, e.CannotFindField(fieldName)
+	}
+
+	t.indexesLock.Lock()
+
+	if t.closed {
+		t.indexesLock.Unlock()
+		return IndexBuildProgress{}, e.TableDoesNotExist()
+	}
+
+	if _, ok := t.indexes[fieldName]; ok {
+		t.indexesLock.Unlock()
+		return IndexBuildProgress{}, e.FieldIsAlreadyIndexed(fieldName)
+	}
+
+	if _, ok := t.indexBuilds[fieldName]; ok {
+		t.indexesLock.Unlock()
+		return IndexBuildProgress{}, e.IndexIsAlreadyBeingBuilt(fieldName)
+	}
+
+	b := t.addIndexBuild(fieldName)
+
+	t.indexesLock.Unlock()
+
+	//written before the build starts, it is started again if the table is loaded before it finished
+	err := t.saveConfig()
+
+	if err != nil {
+		t.indexesLock.Lock()
+
+		if t.indexBuilds[fieldName] == b {
+			delete(t.indexBuilds, fieldName)
+			t.setBuilding(fieldName, false)
+		}
+
+		t.indexesLock.Unlock()
+		t.runningBuilds.Done()
+
+		return IndexBuildProgress{}, err
+	}
+
+	return IndexBuildProgress{Total: t.startIndexBuild(fieldName, b)}, nil
+}
+
+// resumeIndexBuilds starts the builds that were still running when the config was written
+func (t *Table) resumeIndexBuilds() {
+	t.indexesLock.Lock()
+
+	builds := map[string]*indexBuild{}
+
+	for _, fieldName := range pendingIndexBuilds(t.Config.IndexBuilds, t.Config.Fields) {
+		builds[fieldName] = t.addIndexBuild(fieldName)
+	}
+
+	t.indexesLock.Unlock()
+
+	for fieldName, b := range builds {
+		t.logger.Println("resuming build of index of field " + fieldName + " in table " + t.Name)
+
+		t.startIndexBuild(fieldName, b)
+	}
+}
+
+// addIndexBuild registers a new build of the index of fieldName, the caller has to hold indexesLock
+func (t *Table) addIndexBuild(fieldName string) *indexBuild {
+	b := &indexBuild{
+		index: index.NewIndex(),
+	}
+
+	t.indexBuilds[fieldName] = b
+	t.runningBuilds.Add(1)
+	t.setBuilding(fieldName, true)
+
+	return b
+}
+
+// startIndexBuild starts building the index from the existing objects and returns their number
+func (t *Table) startIndexBuild(fieldName string, b *indexBuild) int64 {
+	//objects written from now on are indexed by the build directly, only the existing ones have to be read
+	ids := t.Storage.Ids()
+
+	t.indexesLock.Lock()
+	b.total = int64(len(ids))
+	t.indexesLock.Unlock()
+
+	go t.buildIndex(fieldName, b, ids)
+
+	return int64(len(ids))
+}
+
+func (t *Table) DropIndex(fieldName string) error {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	f, ok := t.Config.Fields[fieldName]
+
+	if !ok || fieldName == field.InternalObjectIdField {
+		return e.CannotFindField(fieldName)
+	}
+
+	if f.Unique || t.isCombinedUnique(fieldName) {
+		return e.CannotDropIndexOfUniqueField(fieldName)
+	}
+
+	t.indexesLock.Lock()
+
+	//cancels the running build
+	if _, ok := t.indexBuilds[fieldName]; ok {
+		delete(t.indexBuilds, fieldName)
+		t.setBuilding(fieldName, false)
+		t.indexesLock.Unlock()
+		return t.saveConfig()
+	}
+
+	if _, ok := t.indexes[fieldName]; !ok {
+		t.indexesLock.Unlock()
+		return e.FieldIsNotIndexed(fieldName)
+	}
+
+	delete(t.indexes, fieldName)
+	t.setIndexed(fieldName, false)
+
+	t.indexesLock.Unlock()
+
+	return t.savedIndexChange()
+}
+
+func (t *Table) IndexBuilds() map[string]IndexBuildProgress {
+	t.indexesLock.RLock()
+	defer t.indexesLock.RUnlock()
+
+	progress := map[string]IndexBuildProgress{}
+
+	for fieldName, b := range t.indexBuilds {
+		progress[fieldName] = IndexBuildProgress{
+			Processed: b.processed,
+			Total:     b.total,
+		}
+	}
+
+	return progress
+}
+
+func (t *Table) buildIndex(fieldName string, b *indexBuild, ids []int64) {
+	defer t.runningBuilds.Done()
+
+	for start := 0; start < len(ids); start += indexBuildBatchSize {
+		end := start + indexBuildBatchSize
+
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		if !t.isBuilding(fieldName, b) {
+			return
+		}
+
+		objects, err := t.Storage.GetObjects(ids[start:end])
+
+		if err != nil {
+			t.indexesLock.Lock()
+
+			if t.indexBuilds[fieldName] == b {
+				delete(t.indexBuilds, fieldName)
+			}
+
+			t.indexesLock.Unlock()
+
+			//stays in the config, the build is started again when the table is loaded
+			t.logger.Println("failed to build index of field " + fieldName + " in table " + t.Name + ": " + err.Error())
+			return
+		}
+
+		t.indexesLock.Lock()
+
+		if t.indexBuilds[fieldName] != b {
+			t.indexesLock.Unlock()
+			return
+		}
+
+		for _, o := range objects {
+			value := o.M[fieldName]
+
+			//removed or already indexed with a newer value while the batch was read
+			if value == nil || !t.Storage.Exists(o.Id) || b.index.GetValue(o.Id) != nil {
+				continue
+			}
+
+			b.index.Add(value, o.Id)
+		}
+
+		b.processed = int64(end)
+
+		t.indexesLock.Unlock()
+	}
+
+	t.indexesLock.Lock()
+
+	if t.indexBuilds[fieldName] != b {
+		t.indexesLock.Unlock()
+		return
+	}
+
+	delete(t.indexBuilds, fieldName)
+	t.indexes[fieldName] = b.index
+	t.setIndexed(fieldName, true)
+	t.setBuilding(fieldName, false)
+
+	t.indexesLock.Unlock()
+
+	err := t.savedIndexChange()
+
+	if err != nil {
+		t.logger.Println("failed to save index of field " + fieldName + " in table " + t.Name + ": " + err.Error())
+		return
+	}
+
+	t.logger.Println("built index of field " + fieldName + " in table " + t.Name)
+}
+
+func (t *Table) isBuilding(fieldName string, b *indexBuild) bool {
+	t.indexesLock.RLock()
+	defer t.indexesLock.RUnlock()
+
+	return t.indexBuilds[fieldName] == b
+}
+
+func (t *Table) isCombinedUnique(fieldName string) bool {
+	for _, fieldNames := range t.Config.Options.CombinedUniques {
+		for _, name := range fieldNames {
+			if name == fieldName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// IsUnique returns if at most one object can have the values for fieldNames,
+// because one of them is unique or they contain all fields of a combined unique
+func (t *Table) IsUnique(fieldNames []string) bool {
+	contains := map[string]bool{}
+
+	for _, name := range fieldNames {
+		if t.Config.Fields[name].Unique {
+			return true
+		}
+
+		contains[name] = true
+	}
+
+	for _, combined := range t.Config.Options.CombinedUniques {
+		all := len(combined) > 0
+
+		for _, name := range combined {
+			all = all && contains[name]
+		}
+
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *Table) setIndexed(fieldName string, indexed bool) {
+	//queries read the fields without locking, never modify the map they are using
+	fields := make(map[string]field.Field, len(t.Config.Fields))
+
+	for name, f := range t.Config.Fields {
+		fields[name] = f
+	}
+
+	f := fields[fieldName]
+	f.Indexed = indexed
+	fields[fieldName] = f
+
+	t.Config.Fields = fields
+	t.Storage.SetFields(fields)
+}
+
+func (t *Table) setBuilding(fieldName string, building bool) {
+	for _, name := range t.Config.IndexBuilds {
+		if name == fieldName && building {
+			return
+		}
+	}
+
+	//the config is read without locking as well, never modify the slice it is using
+	var builds []string
+
+	for _, name := range t.Config.IndexBuilds {
+		if name != fieldName {
+			builds = append(builds, name)
+		}
+	}
+
+	if building {
+		builds = append(builds, fieldName)
+	}
+
+	t.Config.IndexBuilds = pendingIndexBuilds(builds, t.Config.Fields)
+}
+
+// pendingIndexBuilds returns the builds of fields that still exist and are not indexed yet
+func pendingIndexBuilds(builds []string, fields map[string]field.Field) []string {
+	var pending []string
+
+	for _, fieldName := range builds {
+		if f, ok := fields[fieldName]; ok && !f.Indexed {
+			pending = append(pending, fieldName)
+		}
+	}
+
+	return pending
+}
+
+func (t *Table) savedIndexChange() error {
+	err := t.saveConfig()
+
+	if err != nil {
+		return err
+	}
+
+	//the last checkpoint was written for the old fields and would be rejected as stale
+	return t.Storage.Checkpoint()
+}
@@ -12,6 +12,8 @@ import (
 var QueryMiddleware func(table *Table, q Query) (bool, func(previousObjects object.Objects) (object.Objects, AdditionalFields, error))
 var InsertMiddleware func(table *Table, objectM map[string]json.RawMessage) (bool, func() error)
 var UpdateMiddleware func(table *Table, objectM map[string]json.RawMessage) (bool, func() error)
+var UpsertMiddleware func(table *Table, objectM map[string]json.RawMessage) (bool, func() (bool, error))
+var UpdateWhereMiddleware func(table *Table, updates []FieldUpdate) (bool, func() (int64, int64, error))
 var RemoveMiddleware func(table *Table, object *object.Object) (bool, func() error)
 var CreateDatabaseMiddleware func(name string) (bool, func() error)
 
@@ -34,6 +36,18 @@ func init() {
 		}
 	}
 
+	UpsertMiddleware = func(table *Table, objectM map[string]json.RawMessage) (bool, func() (bool, error)) {
+		return false, func() (bool, error) {
+			return false, nil
+		}
+	}
+
+	UpdateWhereMiddleware = func(table *Table, updates []FieldUpdate) (bool, func() (int64, int64, error)) {
+		return false, func() (int64, int64, error) {
+			return 0, 0, nil
+		}
+	}
+
 	RemoveMiddleware = func(table *Table, object *object.Object) (bool, func() error) {
 		return false, func() error {
 			return nil
@@ -0,0 +1,517 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/index"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+	"sort"
+)
+
+// plan is a conjunction, all of its conjuncts have to match
+type plan struct {
+	conjuncts []*conjunct
+
+	//estimated number of matching objects
+	estimate int
+}
+
+// conjunct is either a single where, a union of alternative plans or the complement of such a union
+type conjunct struct {
+	leaf  *leaf
+	anyOf []*plan
+	not   []*plan
+
+	//estimated number of matching objects
+	estimate int
+}
+
+type leaf struct {
+	where     request.Where
+	predicate *predicate
+
+	//nil if the field is not indexed and has to be scanned
+	index *index.Index
+}
+
+// planQuery turns q into alternative plans whose results are united, it returns false if q contains functions
+// or is handled by a middleware, those have to be run in the order they were given
+func (t *Table) planQuery(q Query) ([]*plan, bool, error) {
+	runMiddleware, _ := QueryMiddleware(t, q)
+
+	if runMiddleware || len(q.Functions) > 0 {
+		return nil, false, nil
+	}
+
+	p := &plan{}
+
+	if q.Where != nil {
+		conjuncts, err := t.planWhere(*q.Where)
+
+		if err != nil {
+			return nil, true, err
+		}
+
+		p.conjuncts = append(p.conjuncts, conjuncts...)
+	}
+
+	for _, and := range q.And {
+		alternatives, ok, err := t.planQuery(and)
+
+		if !ok || err != nil {
+			return nil, ok, err
+		}
+
+		if len(alternatives) == 1 {
+			p.conjuncts = append(p.conjuncts, alternatives[0].conjuncts...)
+		} else {
+			p.conjuncts = append(p.conjuncts, &conjunct{anyOf: alternatives})
+		}
+	}
+
+	if len(q.Not) > 0 {
+		//an object is removed if it matches any of the negated queries
+		c := &conjunct{}
+
+		for _, not := range q.Not {
+			alternatives, ok, err := t.planQuery(not)
+
+			if !ok || err != nil {
+				return nil, ok, err
+			}
+
+			c.not = append(c.not, alternatives...)
+		}
+
+		p.conjuncts = append(p.conjuncts, c)
+	}
+
+	var alternatives []*plan
+
+	//a query that only consists of or does not match every object by itself
+	if q.hasConditions() || len(q.Or) == 0 {
+		alternatives = append(alternatives, p)
+	}
+
+	for _, or := range q.Or {
+		plans, ok, err := t.planQuery(or)
+
+		if !ok || err != nil {
+			return nil, ok, err
+		}
+
+		alternatives = append(alternatives, plans...)
+	}
+
+	return alternatives, true, nil
+}
+
+func (t *Table) planWhere(w request.Where) ([]*conjunct, error) {
+	if len(w.All) > 0 {
+		var conjuncts []*conjunct
+
+		for _, value := range w.All {
+			l, err := t.newLeaf(request.Where{Field: w.Field, Operator: w.Operator, Value: value, CaseInsensitive: w.CaseInsensitive})
+
+			if err != nil {
+				return nil, err
+			}
+
+			conjuncts = append(conjuncts, &conjunct{leaf: l})
+		}
+
+		return conjuncts, nil
+	}
+
+	if len(w.Any) > 0 {
+		c := &conjunct{}
+
+		for _, value := range w.Any {
+			l, err := t.newLeaf(request.Where{Field: w.Field, Operator: w.Operator, Value: value, CaseInsensitive: w.CaseInsensitive})
+
+			if err != nil {
+				return nil, err
+			}
+
+			c.anyOf = append(c.anyOf, &plan{conjuncts: []*conjunct{{leaf: l}}})
+		}
+
+		return []*conjunct{c}, nil
+	}
+
+	l, err := t.newLeaf(w)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []*conjunct{{leaf: l}}, nil
+}
+
+func (t *Table) newLeaf(w request.Where) (*leaf, error) {
+	f, ok := t.Config.Fields[w.Field]
+
+	if !ok {
+		return nil, e.CannotFindField(w.Field)
+	}
+
+	p, err := newPredicate(w, f)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := &leaf{
+		where:     w,
+		predicate: p,
+	}
+
+	if i, err := t.GetIndex(w.Field); err == nil {
+		l.index = i
+	}
+
+	return l, nil
+}
+
+func (t *Table) runPlans(alternatives []*plan, andObjects object.Objects, explain *ExplainStage) (object.Objects, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	size := t.Size()
+
+	for _, p := range alternatives {
+		t.estimatePlan(p, size)
+	}
+
+	if len(alternatives) == 1 {
+		return t.runPlan(alternatives[0], andObjects, explain)
+	}
+
+	return t.runAnyOf(alternatives, andObjects, explain)
+}
+
+func (t *Table) runPlan(p *plan, andObjects object.Objects, explain *ExplainStage) (object.Objects, error) {
+	s := explain.Add(NewExplainStage(AndStage))
+	s.setEstimate(p.estimate)
+
+	objects := andObjects
+
+	for _, c := range p.conjuncts {
+		//nothing left that could match the remaining conjuncts
+		if objects != nil && len(objects) == 0 {
+			break
+		}
+
+		var err error
+
+		switch {
+		case c.leaf != nil:
+			objects, err = t.runLeaf(c.leaf, c.estimate, objects, s)
+		case c.not != nil:
+			objects, err = t.runNot(c.not, objects, s)
+		default:
+			objects, err = t.runAnyOf(c.anyOf, objects, s)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t.finish(s, andObjects, objects)
+
+	return objects, nil
+}
+
+func (t *Table) runAnyOf(alternatives []*plan, andObjects object.Objects, explain *ExplainStage) (object.Objects, error) {
+	s := explain.Add(NewExplainStage(OrStage))
+
+	results := object.Objects{}
+	found := map[int64]bool{}
+
+	for _, p := range alternatives {
+		objects, err := t.runPlan(p, andObjects, s)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range objects {
+			if !found[id] {
+				found[id] = true
+				results = append(results, id)
+			}
+		}
+	}
+
+	t.finish(s, andObjects, results)
+
+	return results, nil
+}
+
+// runNot returns the objects of andObjects that do not match any of the alternatives, if andObjects is nil the whole table is considered
+func (t *Table) runNot(alternatives []*plan, andObjects object.Objects, explain *ExplainStage) (object.Objects, error) {
+	s := explain.Add(NewExplainStage(NotStage))
+
+	var excluded object.Objects
+	var err error
+
+	if len(alternatives) == 1 {
+		excluded, err = t.runPlan(alternatives[0], andObjects, s)
+	} else {
+		excluded, err = t.runAnyOf(alternatives, andObjects, s)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if andObjects == nil {
+		s.SetAccess(IndexLookupAccess, field.InternalObjectIdField)
+	}
+
+	results := t.complement(andObjects, excluded)
+
+	t.finish(s, andObjects, results)
+
+	return results, nil
+}
+
+// complement returns the objects of andObjects that are not excluded, if andObjects is nil the internal object id index is walked instead
+func (t *Table) complement(andObjects object.Objects, excluded object.Objects) object.Objects {
+	results := object.Objects{}
+
+	//nil means every object was matched
+	if excluded == nil {
+		return results
+	}
+
+	skip := make(map[int64]bool, len(excluded))
+
+	for _, id := range excluded {
+		skip[id] = true
+	}
+
+	if andObjects != nil {
+		for _, id := range andObjects {
+			if !skip[id] {
+				results = append(results, id)
+			}
+		}
+
+		return results
+	}
+
+	i, err := t.GetIndex(field.InternalObjectIdField)
+
+	if err != nil {
+		return results
+	}
+
+	i.Ascend(func(_ dbtype.DBType, id int64) bool {
+		if !skip[id] {
+			results = append(results, id)
+		}
+
+		return true
+	})
+
+	return results
+}
+
+// runLeaf returns the objects matching l, if andObjects is not nil only those are considered
+func (t *Table) runLeaf(l *leaf, estimate int, andObjects object.Objects, explain *ExplainStage) (object.Objects, error) {
+	s := explain.Add(whereStage(l.where))
+	s.setEstimate(estimate)
+
+	var results object.Objects
+	var err error
+
+	switch {
+	case l.index == nil:
+		s.SetAccess(ScanAccess, "")
+		results, err = t.scan(l.where.Field, l.predicate, andObjects)
+	case andObjects == nil:
+		s.SetAccess(IndexLookupAccess, l.where.Field)
+		results = t.lookup(l)
+	case estimate >= len(andObjects):
+		//checking every object is cheaper than looking up more objects than there are left
+		s.SetAccess(IndexFilterAccess, l.where.Field)
+		results = t.andPredicate(andObjects, l.index, l.predicate)
+	default:
+		s.SetAccess(IndexIntersectAccess, l.where.Field)
+		results = intersect(andObjects, t.lookup(l))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.finish(s, andObjects, results)
+
+	return results, nil
+}
+
+func (t *Table) lookup(l *leaf) object.Objects {
+	var results object.Objects
+
+	switch l.predicate.operator {
+	case request.MATCH:
+		results = l.index.Match(*l.predicate.regex)
+	case request.BETWEEN:
+		results = l.index.Between(l.predicate.smaller, l.predicate.larger)
+	case request.EQUALS:
+		results = l.index.Equal(l.predicate.value)
+	case request.NOT:
+		results = l.index.Not(l.predicate.value)
+	case request.SMALLER:
+		results = l.index.Smaller(l.predicate.value)
+	case request.LARGER:
+		results = l.index.Larger(l.predicate.value)
+	case request.SMALLER_OR_EQUAL:
+		results = l.index.SmallerOrEqual(l.predicate.value)
+	case request.LARGER_OR_EQUAL:
+		results = l.index.LargerOrEqual(l.predicate.value)
+	case request.IN:
+		results = l.index.In(l.predicate.values)
+	case request.NOT_IN:
+		results = l.index.NotIn(l.predicate.values)
+	case request.IS_NULL:
+		results = l.index.IsNull()
+	case request.IS_NOT_NULL:
+		results = l.index.IsNotNull()
+	case request.STARTS_WITH:
+		results = l.index.StartsWith(l.predicate.text, l.predicate.caseInsensitive)
+	case request.ENDS_WITH:
+		results = l.index.EndsWith(l.predicate.text, l.predicate.caseInsensitive)
+	case request.CONTAINS:
+		results = l.index.Contains(l.predicate.text, l.predicate.caseInsensitive)
+	}
+
+	//nil would mean no restriction for the next conjunct
+	if results == nil {
+		return object.Objects{}
+	}
+
+	return results
+}
+
+// estimatePlan estimates the number of results of p and sorts its conjuncts so the most selective ones run first
+func (t *Table) estimatePlan(p *plan, size int) int {
+	p.estimate = size
+
+	for _, c := range p.conjuncts {
+		switch {
+		case c.leaf != nil:
+			c.estimate = estimateLeaf(c.leaf, size)
+		case c.not != nil:
+			c.estimate = size - t.estimateAnyOf(c.not, size)
+		default:
+			c.estimate = t.estimateAnyOf(c.anyOf, size)
+		}
+
+		if c.estimate < p.estimate {
+			p.estimate = c.estimate
+		}
+	}
+
+	//scans only get cheaper the more objects were filtered out before them
+	sort.SliceStable(p.conjuncts, func(a, b int) bool {
+		aScan, bScan := isScan(p.conjuncts[a]), isScan(p.conjuncts[b])
+
+		if aScan != bScan {
+			return bScan
+		}
+
+		return p.conjuncts[a].estimate < p.conjuncts[b].estimate
+	})
+
+	return p.estimate
+}
+
+func (t *Table) estimateAnyOf(alternatives []*plan, size int) int {
+	estimate := 0
+
+	for _, alternative := range alternatives {
+		estimate += t.estimatePlan(alternative, size)
+	}
+
+	if estimate > size {
+		return size
+	}
+
+	return estimate
+}
+
+func estimateLeaf(l *leaf, size int) int {
+	if l.index == nil {
+		return size
+	}
+
+	switch l.predicate.operator {
+	//counted without collecting the objects, runLeaf looks them up again
+	case request.EQUALS:
+		return l.index.CountEqual(l.predicate.value)
+	case request.NOT:
+		return size - l.index.CountEqual(l.predicate.value)
+	case request.IN:
+		return l.index.CountIn(l.predicate.values)
+	case request.NOT_IN:
+		return size - l.index.CountIn(l.predicate.values)
+	case request.SMALLER, request.LARGER, request.BETWEEN, request.SMALLER_OR_EQUAL, request.LARGER_OR_EQUAL, request.STARTS_WITH:
+		return size / 3
+	}
+
+	return size / 2
+}
+
+func isScan(c *conjunct) bool {
+	if c.leaf != nil {
+		return c.leaf.index == nil
+	}
+
+	for _, p := range append(c.anyOf, c.not...) {
+		for _, alternative := range p.conjuncts {
+			if isScan(alternative) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (t *Table) Size() int {
+	i, err := t.GetIndex(field.InternalObjectIdField)
+
+	if err != nil {
+		return 0
+	}
+
+	return i.Len()
+}
+
+func intersect(objects object.Objects, otherObjects object.Objects) object.Objects {
+	contained := make(map[int64]bool, len(otherObjects))
+
+	for _, id := range otherObjects {
+		contained[id] = true
+	}
+
+	results := object.Objects{}
+
+	for _, id := range objects {
+		if contained[id] {
+			results = append(results, id)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+	"github.com/lucasl0st/InfiniteDB/util"
+	"regexp"
+	"strings"
+)
+
+type predicate struct {
+	operator        request.Operator
+	null            dbtype.DBType
+	value           dbtype.DBType
+	values          []dbtype.DBType
+	smaller         dbtype.DBType
+	larger          dbtype.DBType
+	regex           *regexp.Regexp
+	text            string
+	caseInsensitive bool
+}
+
+func newPredicate(w request.Where, f field.Field) (*predicate, error) {
+	null, err := idbutil.NullDBType(f)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &predicate{
+		operator: w.Operator,
+		null:     null,
+	}
+
+	switch w.Operator {
+	case request.MATCH:
+		s, err := util.JsonRawToString(w.Value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if s == nil {
+			return nil, errors.New("cannot be null for match")
+		}
+
+		p.regex, err = regexp.Compile(*s)
+
+		if err != nil {
+			return nil, err
+		}
+	case request.BETWEEN:
+		s, err := util.JsonRawToString(w.Value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if s == nil {
+			return nil, errors.New("cannot be null for between")
+		}
+
+		values := strings.Split(*s, "_")
+
+		if len(values) <= 1 {
+			return nil, e.NotEnoughValuesForOperator(w.Operator)
+		}
+
+		p.smaller, err = idbutil.StringToDBType(values[0], f)
+
+		if err != nil {
+			return nil, err
+		}
+
+		p.larger, err = idbutil.StringToDBType(values[1], f)
+
+		if err != nil {
+			return nil, err
+		}
+	case request.EQUALS, request.NOT, request.SMALLER, request.LARGER, request.SMALLER_OR_EQUAL, request.LARGER_OR_EQUAL:
+		p.value, err = idbutil.JsonRawToDBType(w.Value, f)
+
+		if err != nil {
+			return nil, err
+		}
+	case request.IN, request.NOT_IN:
+		var values []json.RawMessage
+
+		err = json.Unmarshal(w.Value, &values)
+
+		if err != nil {
+			return nil, e.ValueForOperatorMustBeArray(w.Operator)
+		}
+
+		for _, value := range values {
+			v, err := idbutil.JsonRawToDBType(value, f)
+
+			if err != nil {
+				return nil, err
+			}
+
+			p.values = append(p.values, v)
+		}
+	case request.IS_NULL, request.IS_NOT_NULL:
+	case request.STARTS_WITH, request.ENDS_WITH, request.CONTAINS:
+		if f.Type != dbtype.TEXT {
+			return nil, e.OperatorNeedsTextField(w.Operator, f.Name)
+		}
+
+		s, err := util.JsonRawToString(w.Value)
+
+		if err != nil || s == nil {
+			return nil, e.ValueForOperatorMustBeString(w.Operator)
+		}
+
+		p.text = *s
+		p.caseInsensitive = w.CaseInsensitive
+	default:
+		return nil, e.NotAValidOperator()
+	}
+
+	return p, nil
+}
+
+func (p *predicate) matches(value dbtype.DBType) bool {
+	//objects without a value for the field are treated as null
+	if value == nil {
+		value = p.null
+	}
+
+	switch p.operator {
+	case request.MATCH:
+		return value.Matches(*p.regex)
+	case request.BETWEEN:
+		return value.Between(p.smaller, p.larger)
+	case request.EQUALS:
+		return value.Equal(p.value)
+	case request.NOT:
+		return value.Not(p.value)
+	case request.SMALLER:
+		return value.Smaller(p.value)
+	case request.LARGER:
+		return value.Larger(p.value)
+	case request.SMALLER_OR_EQUAL:
+		return !value.IsNull() && !value.Larger(p.value) && !p.value.IsNull()
+	case request.LARGER_OR_EQUAL:
+		return !value.IsNull() && !value.Smaller(p.value) && !p.value.IsNull()
+	case request.IN:
+		return p.in(value)
+	case request.NOT_IN:
+		return !p.in(value)
+	case request.IS_NULL:
+		return value.IsNull()
+	case request.IS_NOT_NULL:
+		return !value.IsNull()
+	case request.STARTS_WITH:
+		return value.(dbtype.Text).StartsWith(p.text, p.caseInsensitive)
+	case request.ENDS_WITH:
+		return value.(dbtype.Text).EndsWith(p.text, p.caseInsensitive)
+	case request.CONTAINS:
+		return value.(dbtype.Text).Contains(p.text, p.caseInsensitive)
+	}
+
+	return false
+}
+
+func (p *predicate) in(value dbtype.DBType) bool {
+	for _, v := range p.values {
+		if !value.Not(v) {
+			return true
+		}
+	}
+
+	return false
+}
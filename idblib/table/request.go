@@ -11,20 +11,66 @@ import (
 
 type Request struct {
 	Query     *Query
-	Sort      *request.Sort
-	Implement []request.Implement
+	Sort      request.SortKeys
+	Implement []Implement
 	Skip      *int64
 	Limit     *int64
+	Fields    []string
+	Exclude   []string
+	Aggregate *request.Aggregate
+	Explain   bool
+	Cursor    *Cursor
 }
 
 type Query struct {
 	Where     *request.Where
 	Functions []FunctionWithParameters
-	And       *Query
-	Or        *Query
+	//all of And have to match
+	And []Query
+	//the objects matching any of Or are added
+	Or []Query
+	//the objects matching any of Not are removed
+	Not []Query
+}
+
+// hasConditions returns if the query restricts the objects before Or is added
+func (q Query) hasConditions() bool {
+	return q.Where != nil || len(q.Functions) > 0 || len(q.And) > 0 || len(q.Not) > 0
+}
+
+type Implement struct {
+	Table      string
+	On         []request.ImplementOn
+	Type       request.ImplementType
+	As         string
+	ForceArray bool
+	Query      *Query
+	Fields     []string
+	Sort       request.SortKeys
+	Limit      *int64
+	Implement  []Implement
+}
+
+// KeyFields returns the fields of the objects that are matched with the other table
+func (i Implement) KeyFields() []string {
+	var fieldNames []string
+
+	for _, on := range i.On {
+		fieldNames = append(fieldNames, on.Field)
+	}
+
+	return fieldNames
+}
+
+// FieldUpdate changes the value of a field with an update operator
+type FieldUpdate struct {
+	Operator request.UpdateOperator
+	Field    string
+	Value    json.RawMessage
 }
 
 type FunctionWithParameters struct {
+	Name       string
 	Function   Function
 	Parameters map[string]json.RawMessage
 }
@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+)
+
+const scanBatchSize = 1000
+
+// scan evaluates p on the stored objects of a field that is not indexed, if andObjects is nil the whole table is scanned
+func (t *Table) scan(fieldName string, p *predicate, andObjects object.Objects) (object.Objects, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	ids := []int64(andObjects)
+
+	if andObjects == nil {
+		ids = t.Storage.Ids()
+	}
+
+	if t.scanLimit > 0 && uint(len(ids)) > t.scanLimit {
+		return nil, e.ScanLimitExceeded(fieldName, len(ids), t.scanLimit)
+	}
+
+	results := object.Objects{}
+
+	for start := 0; start < len(ids); start += scanBatchSize {
+		end := start + scanBatchSize
+
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		objects, err := t.Storage.GetObjects(ids[start:end])
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range objects {
+			if p.matches(o.M[fieldName]) {
+				results = append(results, o.Id)
+			}
+		}
+	}
+
+	return results, nil
+}
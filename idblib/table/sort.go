@@ -0,0 +1,295 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"container/heap"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/index"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+	"sort"
+)
+
+type sortKey struct {
+	request.Sort
+
+	//nil if the values of the key only are in the additional fields
+	index *index.Index
+}
+
+// sortEntry holds the values of the sort keys of an object
+type sortEntry struct {
+	id     int64
+	values []dbtype.DBType
+}
+
+// Sort orders the objects by keys, objects with equal values for all keys are ordered by their id in the direction of the last key.
+// If k is not nil only the first k objects are sorted and returned
+func (t *Table) Sort(o object.Objects, keys request.SortKeys, additionalFields AdditionalFields, k *int64) (object.Objects, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	if len(o) == 0 {
+		return o, nil
+	}
+
+	sortKeys, err := t.sortKeys(keys, o, additionalFields)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if k != nil && *k < int64(len(o)) {
+		entries := topK(t.sortEntries(o, sortKeys, additionalFields), sortKeys, int(*k))
+
+		return entryIds(entries), nil
+	}
+
+	if len(sortKeys) == 1 && sortKeys[0].index != nil && !hasAdditionalValues(o, sortKeys[0].Field, additionalFields) {
+		return t.sortByIndex(o, sortKeys[0]), nil
+	}
+
+	entries := t.sortEntries(o, sortKeys, additionalFields)
+
+	sort.Slice(entries, func(a, b int) bool {
+		return compareEntries(sortKeys, entries[a], entries[b]) < 0
+	})
+
+	return entryIds(entries), nil
+}
+
+// sortKeys looks up the index of every key, keys that are not indexed must be additional fields of the objects
+func (t *Table) sortKeys(keys request.SortKeys, o object.Objects, additionalFields AdditionalFields) ([]sortKey, error) {
+	var sortKeys []sortKey
+
+	for _, key := range keys {
+		i, err := t.GetIndex(key.Field)
+
+		if err != nil {
+			if !hasAdditionalValues(o, key.Field, additionalFields) {
+				return nil, err
+			}
+
+			i = nil
+		}
+
+		sortKeys = append(sortKeys, sortKey{Sort: key, index: i})
+	}
+
+	return sortKeys, nil
+}
+
+func (t *Table) sortEntries(o object.Objects, keys []sortKey, additionalFields AdditionalFields) []sortEntry {
+	entries := make([]sortEntry, 0, len(o))
+
+	for _, id := range o {
+		entry := sortEntry{
+			id:     id,
+			values: make([]dbtype.DBType, len(keys)),
+		}
+
+		for k, key := range keys {
+			value := additionalFields[id][key.Field]
+
+			if value == nil && key.index != nil {
+				value = key.index.GetValue(id)
+			}
+
+			entry.values[k] = value
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// sortByIndex walks the sorted index and keeps the requested objects, they come out already ordered
+func (t *Table) sortByIndex(o object.Objects, key sortKey) object.Objects {
+	wanted := make(map[int64]bool, len(o))
+
+	var nulls []int64
+
+	for _, id := range o {
+		if isNull(key.index.GetValue(id)) {
+			nulls = append(nulls, id)
+		} else {
+			wanted[id] = true
+		}
+	}
+
+	sortIds(nulls, key.Direction)
+
+	results := make(object.Objects, 0, len(o))
+
+	if key.Nulls == request.NULLS_FIRST {
+		results = append(results, nulls...)
+	}
+
+	if len(wanted) > 0 {
+		collect := func(_ dbtype.DBType, id int64) bool {
+			if wanted[id] {
+				results = append(results, id)
+				delete(wanted, id)
+			}
+
+			return len(wanted) > 0
+		}
+
+		if key.Direction == request.DESC {
+			key.index.DescendBefore(nil, 0, collect)
+		} else {
+			key.index.AscendAfter(nil, 0, collect)
+		}
+	}
+
+	if key.Nulls != request.NULLS_FIRST {
+		results = append(results, nulls...)
+	}
+
+	return results
+}
+
+// compareEntries returns a negative number if a comes before b, a positive number if it comes after b
+func compareEntries(keys []sortKey, a sortEntry, b sortEntry) int {
+	for k, key := range keys {
+		if c := compareSortValues(key.Sort, a.values[k], b.values[k]); c != 0 {
+			return c
+		}
+	}
+
+	var direction request.SortDirection
+
+	if len(keys) > 0 {
+		direction = keys[len(keys)-1].Direction
+	}
+
+	switch {
+	case a.id == b.id:
+		return 0
+	case (a.id < b.id) == (direction != request.DESC):
+		return -1
+	}
+
+	return 1
+}
+
+func compareSortValues(key request.Sort, a dbtype.DBType, b dbtype.DBType) int {
+	aNull, bNull := isNull(a), isNull(b)
+
+	if aNull || bNull {
+		if aNull && bNull {
+			return 0
+		}
+
+		//null values come last unless they are requested first
+		if aNull == (key.Nulls == request.NULLS_FIRST) {
+			return -1
+		}
+
+		return 1
+	}
+
+	c := 0
+
+	if a.Smaller(b) {
+		c = -1
+	} else if a.Larger(b) {
+		c = 1
+	}
+
+	if key.Direction == request.DESC {
+		return -c
+	}
+
+	return c
+}
+
+// topK returns the first k entries in order without sorting all of them
+func topK(entries []sortEntry, keys []sortKey, k int) []sortEntry {
+	if k <= 0 {
+		return nil
+	}
+
+	//a max-heap of the k smallest entries seen so far, its root is the first one to be replaced
+	h := &entryHeap{keys: keys}
+
+	for _, entry := range entries {
+		if len(h.entries) < k {
+			heap.Push(h, entry)
+		} else if compareEntries(keys, entry, h.entries[0]) < 0 {
+			h.entries[0] = entry
+			heap.Fix(h, 0)
+		}
+	}
+
+	results := make([]sortEntry, len(h.entries))
+
+	for k := len(results) - 1; k >= 0; k-- {
+		results[k] = heap.Pop(h).(sortEntry)
+	}
+
+	return results
+}
+
+type entryHeap struct {
+	entries []sortEntry
+	keys    []sortKey
+}
+
+func (h *entryHeap) Len() int {
+	return len(h.entries)
+}
+
+func (h *entryHeap) Less(a, b int) bool {
+	return compareEntries(h.keys, h.entries[a], h.entries[b]) > 0
+}
+
+func (h *entryHeap) Swap(a, b int) {
+	h.entries[a], h.entries[b] = h.entries[b], h.entries[a]
+}
+
+func (h *entryHeap) Push(x any) {
+	h.entries = append(h.entries, x.(sortEntry))
+}
+
+func (h *entryHeap) Pop() any {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+
+	return last
+}
+
+func hasAdditionalValues(o object.Objects, fieldName string, additionalFields AdditionalFields) bool {
+	for _, id := range o {
+		if additionalFields[id][fieldName] != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortIds(ids []int64, direction request.SortDirection) {
+	sort.Slice(ids, func(a, b int) bool {
+		if direction == request.DESC {
+			return ids[a] > ids[b]
+		}
+
+		return ids[a] < ids[b]
+	})
+}
+
+func entryIds(entries []sortEntry) object.Objects {
+	results := make(object.Objects, 0, len(entries))
+
+	for _, entry := range entries {
+		results = append(results, entry.id)
+	}
+
+	return results
+}
@@ -7,7 +7,6 @@ package table
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
 	"github.com/lucasl0st/InfiniteDB/idblib/field"
 	"github.com/lucasl0st/InfiniteDB/idblib/index"
@@ -17,11 +16,8 @@ import (
 	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
 	e "github.com/lucasl0st/InfiniteDB/models/errors"
 	"github.com/lucasl0st/InfiniteDB/models/request"
-	"github.com/lucasl0st/InfiniteDB/util"
 	"os"
-	"regexp"
-	"sort"
-	"strings"
+	"sync"
 )
 
 type Table struct {
@@ -31,10 +27,19 @@ type Table struct {
 	Config       field.TableConfig
 
 	//fieldName -> index
-	indexes map[string]*index.Index
+	indexes     map[string]*index.Index
+	indexBuilds map[string]*indexBuild
+	indexesLock sync.RWMutex
+
+	//running index builds, no builds are started once the table is closed
+	runningBuilds sync.WaitGroup
+	closed        bool
 
 	Storage *storage.Storage
 
+	//maximum number of objects a query on a field that is not indexed may scan, 0 is unlimited
+	scanLimit uint
+
 	logger idbutil.Logger
 }
 
@@ -46,6 +51,7 @@ func NewTable(
 	logger idbutil.Logger,
 	metrics *metrics.Metrics,
 	cacheSize uint,
+	scanLimit uint,
 ) (*Table, error) {
 	table := Table{
 		DatabaseName: databaseName,
@@ -53,22 +59,28 @@ func NewTable(
 		path:         path,
 		Config:       config,
 		indexes:      map[string]*index.Index{},
+		indexBuilds:  map[string]*indexBuild{},
+		scanLimit:    scanLimit,
 		logger:       logger,
 	}
 
-	for _, f := range config.Fields {
-		if f.Indexed {
-			table.indexes[f.Name] = index.NewIndex()
-		}
+	table.Config.Fields[field.InternalObjectIdField] = field.Field{
+		Name:    field.InternalObjectIdField,
+		Indexed: true,
+		Unique:  true,
+		Null:    false,
+		Type:    dbtype.NUMBER,
 	}
 
-	table.indexes[field.InternalObjectIdField] = index.NewIndex()
+	table.resetIndexes()
 
 	s, err := storage.NewStorage(
 		path+name+"/",
 		config.Fields,
 		table.addedObject,
 		table.deletedObject,
+		table.resetIndexes,
+		table.indexValues,
 		cacheSize,
 		logger,
 		func() {
@@ -85,224 +97,213 @@ func NewTable(
 
 	table.Storage = s
 
-	table.Config.Fields[field.InternalObjectIdField] = field.Field{
-		Name:    field.InternalObjectIdField,
-		Indexed: true,
-		Unique:  true,
-		Null:    false,
-		Type:    dbtype.NUMBER,
-	}
+	table.resumeIndexBuilds()
 
 	return &table, err
 }
 
-func (t *Table) Delete() error {
+func (t *Table) Kill() {
+	t.stopIndexBuilds()
 	t.Storage.Kill()
-
-	return os.RemoveAll(t.path + t.Name)
 }
 
-func (t *Table) addedObject(object object.Object) {
-	t.index(object)
+// Close stops the index builds and closes the storage after writing a checkpoint of the indexes
+func (t *Table) Close() {
+	t.stopIndexBuilds()
+	t.Storage.Close()
 }
 
-func (t *Table) deletedObject(object object.Object) {
-	t.unIndex(object)
+// stopIndexBuilds cancels the running index builds and waits until they stopped reading from the storage
+func (t *Table) stopIndexBuilds() {
+	t.indexesLock.Lock()
+	t.indexBuilds = map[string]*indexBuild{}
+	t.closed = true
+	t.indexesLock.Unlock()
+
+	t.runningBuilds.Wait()
 }
 
-func (t *Table) GetIndex(fieldName string) (*index.Index, error) {
-	i, ok := t.indexes[fieldName]
+func (t *Table) Delete() error {
+	t.Kill()
 
-	if !ok {
-		return nil, errors.New("not indexed")
-	}
+	return os.RemoveAll(t.path + t.Name)
+}
 
-	return i, nil
+func (t *Table) addedObject(object object.Object) {
+	t.index(object)
 }
 
-func (t *Table) index(object object.Object) {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
+func (t *Table) deletedObject(id int64) {
+	t.unIndex(id)
+}
 
-	for _, f := range t.Config.Fields {
-		if f.Indexed && f.Name != field.InternalObjectIdField {
-			i, err := t.GetIndex(f.Name)
+func (t *Table) resetIndexes() {
+	t.indexesLock.Lock()
+	defer t.indexesLock.Unlock()
 
-			if err != nil {
-				t.logger.Fatal(err.Error())
-			}
+	indexes := map[string]*index.Index{}
 
-			i.Add(object.M[f.Name], object.Id)
+	for _, f := range t.Config.Fields {
+		if f.Indexed {
+			indexes[f.Name] = index.NewIndex()
 		}
 	}
 
-	n, err := dbtype.NumberFromInt64(object.Id)
+	indexes[field.InternalObjectIdField] = index.NewIndex()
 
-	if err != nil {
-		t.logger.Fatal(err.Error())
-	}
-
-	i, err := t.GetIndex(field.InternalObjectIdField)
+	t.indexes = indexes
 
-	if err != nil {
-		t.logger.Fatal(err.Error())
+	//the objects are read again, running builds start over with them
+	for _, b := range t.indexBuilds {
+		b.index = index.NewIndex()
 	}
-
-	i.Add(n, object.Id)
 }
 
-func (t *Table) unIndex(object object.Object) {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
+func (t *Table) indexValues() map[string]map[int64]string {
+	t.indexesLock.RLock()
+	defer t.indexesLock.RUnlock()
 
-	for _, f := range t.Config.Fields {
-		if f.Indexed && f.Name != field.InternalObjectIdField {
-			i, err := t.GetIndex(f.Name)
+	values := map[string]map[int64]string{}
 
-			if err != nil {
-				t.logger.Fatal(err.Error())
-			}
+	for fieldName, i := range t.indexes {
+		if fieldName == field.InternalObjectIdField {
+			continue
+		}
+
+		values[fieldName] = map[int64]string{}
 
-			i.Remove(object.M[f.Name], object.Id)
+		for id, value := range i.Values() {
+			values[fieldName][id] = value.ToString()
 		}
 	}
 
-	i, err := t.GetIndex(field.InternalObjectIdField)
+	return values
+}
 
-	if err != nil {
-		t.logger.Fatal(err.Error())
-	}
+func (t *Table) Compact() (int64, int64, error) {
+	return t.Storage.Compact()
+}
 
-	n, err := dbtype.NumberFromInt64(object.Id)
+func (t *Table) GetIndex(fieldName string) (*index.Index, error) {
+	t.indexesLock.RLock()
+	defer t.indexesLock.RUnlock()
 
-	if err != nil {
-		t.logger.Fatal(err.Error())
-	}
+	i, ok := t.indexes[fieldName]
 
-	i.Remove(n, object.Id)
-}
+	if !ok {
+		return nil, errors.New("not indexed")
+	}
 
-func (t *Table) updateIndex(object object.Object) {
-	t.unIndex(object)
-	t.index(object)
+	return i, nil
 }
 
-func (t *Table) Where(w request.Where, andObjects object.Objects) (object.Objects, error) {
+// IndexedObjects reads the given fields of the objects from the indexes instead of the storage,
+// it returns false if one of the fields is not indexed
+func (t *Table) IndexedObjects(ids []int64, fieldNames []string) ([]object.Object, bool) {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
 
-	f := t.Config.Fields[w.Field]
-
-	i, err := t.GetIndex(w.Field)
-
-	if err != nil {
-		return nil, err
-	}
+	t.indexesLock.RLock()
 
-	var results object.Objects
+	idIndex := t.indexes[field.InternalObjectIdField]
+	indexes := map[string]*index.Index{}
 
-	switch w.Operator {
-	case request.MATCH:
-		s, err := util.JsonRawToString(w.Value)
+	for _, fieldName := range fieldNames {
+		i, ok := t.indexes[fieldName]
 
-		if err != nil {
-			return nil, err
+		if !ok {
+			t.indexesLock.RUnlock()
+			return nil, false
 		}
 
-		if s == nil {
-			return nil, errors.New("cannot be null for match")
-		}
+		indexes[fieldName] = i
+	}
 
-		r, err := regexp.Compile(*s)
+	t.indexesLock.RUnlock()
 
-		if err != nil {
-			return nil, err
-		}
+	objects := make([]object.Object, 0, len(ids))
 
-		if andObjects == nil {
-			results = i.Match(*r)
-		} else {
-			results, err = t.andMatch(andObjects, w.Field, *r)
+	for _, id := range ids {
+		//removed since the query ran
+		if idIndex.GetValue(id) == nil {
+			continue
 		}
-	case request.BETWEEN:
-		s, err := util.JsonRawToString(w.Value)
 
-		if err != nil {
-			return nil, err
+		o := object.Object{
+			Id: id,
+			M:  map[string]dbtype.DBType{},
 		}
 
-		if s == nil {
-			return nil, errors.New("cannot be null for between")
+		for fieldName, i := range indexes {
+			if value := i.GetValue(id); value != nil {
+				o.M[fieldName] = value
+			}
 		}
 
-		values := strings.Split(*s, "_")
+		objects = append(objects, o)
+	}
 
-		if len(values) <= 1 {
-			return nil, e.NotEnoughValuesForOperator(w.Operator)
-		}
+	return objects, true
+}
 
-		smaller, err := idbutil.StringToDBType(values[0], f)
+func (t *Table) index(object object.Object) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
 
-		if err != nil {
-			return nil, err
-		}
+	n, err := dbtype.NumberFromInt64(object.Id)
 
-		larger, err := idbutil.StringToDBType(values[1], f)
+	if err != nil {
+		t.logger.Fatal(err.Error())
+	}
 
-		if andObjects == nil {
-			results = i.Between(smaller, larger)
-		} else {
-			results, err = t.andBetween(andObjects, w.Field, smaller, larger)
-		}
+	t.indexesLock.RLock()
+	defer t.indexesLock.RUnlock()
 
-		if err != nil {
-			return nil, err
+	for fieldName, i := range t.indexes {
+		if fieldName == field.InternalObjectIdField {
+			i.Add(n, object.Id)
+		} else if value := object.M[fieldName]; value != nil {
+			i.Add(value, object.Id)
 		}
-	default:
-		v, err := idbutil.JsonRawToDBType(w.Value, f)
+	}
 
-		if err != nil {
-			return nil, err
+	for fieldName, b := range t.indexBuilds {
+		if value := object.M[fieldName]; value != nil {
+			b.index.Add(value, object.Id)
 		}
+	}
+}
 
-		switch w.Operator {
-		case request.EQUALS:
-			if andObjects == nil {
-				results = i.Equal(v)
-			} else {
-				results, err = t.andEqual(andObjects, w.Field, v)
-			}
-		case request.NOT:
-			if andObjects == nil {
-				results = i.Not(v)
-			} else {
-				results, err = t.andNot(andObjects, w.Field, v)
-			}
-		case request.SMALLER:
-			if andObjects == nil {
-				results = i.Smaller(v)
-			} else {
-				results, err = t.andSmaller(andObjects, w.Field, v)
-			}
-		case request.LARGER:
-			if andObjects == nil {
-				results = i.Larger(v)
-			} else {
-				results, err = t.andLarger(andObjects, w.Field, v)
-			}
-		default:
-			return nil, e.NotAValidOperator()
-		}
+func (t *Table) unIndex(id int64) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
 
-		if err != nil {
-			return nil, err
-		}
+	t.indexesLock.RLock()
+	defer t.indexesLock.RUnlock()
+
+	for _, i := range t.indexes {
+		i.Remove(id)
+	}
+
+	for _, b := range t.indexBuilds {
+		b.index.Remove(id)
+	}
+}
+
+func (t *Table) Where(w request.Where, andObjects object.Objects, explain *ExplainStage) (object.Objects, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	l, err := t.newLeaf(w)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return results, nil
+	return t.runLeaf(l, estimateLeaf(l, t.Size()), andObjects, explain)
 }
 
-func (t *Table) Query(q Query, andObjects object.Objects, additionalFields AdditionalFields) (object.Objects, AdditionalFields, error) {
+func (t *Table) Query(q Query, andObjects object.Objects, additionalFields AdditionalFields, explain *ExplainStage) (object.Objects, AdditionalFields, error) {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
 
@@ -312,68 +313,49 @@ func (t *Table) Query(q Query, andObjects object.Objects, additionalFields Addit
 		return runQuery(andObjects)
 	}
 
-	var objects object.Objects
-	var err error
+	alternatives, planned, err := t.planQuery(q)
 
-	if q.Where != nil {
-		if q.Where.All != nil && len(q.Where.All) > 0 {
-			query := Query{
-				Where: &request.Where{
-					Field:    q.Where.Field,
-					Operator: q.Where.Operator,
-					Value:    q.Where.All[0],
-				},
-			}
-
-			nextQuery := &query
+	if err != nil {
+		return nil, nil, err
+	}
 
-			for _, a := range q.Where.All {
-				if string(a) == string(q.Where.All[0]) {
-					continue
-				}
+	if planned {
+		objects, err := t.runPlans(alternatives, andObjects, explain)
 
-				nextQuery.And = &Query{
-					Where: &request.Where{
-						Field:    q.Where.Field,
-						Operator: q.Where.Operator,
-						Value:    a,
-					},
-				}
-
-				nextQuery = nextQuery.And
-			}
+		if err != nil {
+			return nil, nil, err
+		}
 
-			objects, additionalFields, err = t.Query(query, andObjects, additionalFields)
-		} else if q.Where.Any != nil && len(q.Where.Any) > 0 {
-			query := Query{
-				Where: &request.Where{
-					Field:    q.Where.Field,
-					Operator: q.Where.Operator,
-					Value:    q.Where.Any[0],
-				},
-			}
+		return objects, additionalFields, nil
+	}
 
-			nextQuery := &query
+	//without a where the functions and nested queries continue from the incoming objects
+	objects := andObjects
 
-			for _, a := range q.Where.Any {
-				if string(a) == string(q.Where.Any[0]) {
-					continue
-				}
+	if q.Where != nil {
+		if len(q.Where.All) > 0 || len(q.Where.Any) > 0 {
+			var queries []Query
 
-				nextQuery.Or = &Query{
+			for _, value := range append(q.Where.All, q.Where.Any...) {
+				queries = append(queries, Query{
 					Where: &request.Where{
-						Field:    q.Where.Field,
-						Operator: q.Where.Operator,
-						Value:    a,
+						Field:           q.Where.Field,
+						Operator:        q.Where.Operator,
+						Value:           value,
+						CaseInsensitive: q.Where.CaseInsensitive,
 					},
-				}
+				})
+			}
 
-				nextQuery = nextQuery.Or
+			query := Query{And: queries}
+
+			if len(q.Where.Any) > 0 {
+				query = Query{Or: queries}
 			}
 
-			objects, additionalFields, err = t.Query(query, andObjects, additionalFields)
+			objects, additionalFields, err = t.Query(query, andObjects, additionalFields, explain)
 		} else {
-			objects, err = t.Where(*q.Where, andObjects)
+			objects, err = t.Where(*q.Where, andObjects, explain)
 		}
 
 		if err != nil {
@@ -383,35 +365,91 @@ func (t *Table) Query(q Query, andObjects object.Objects, additionalFields Addit
 
 	if q.Functions != nil {
 		for _, function := range q.Functions {
+			s := NewExplainStage(FunctionStage)
+			s.Function = function.Name
+			s = explain.Add(s)
+
+			before := objects
+
 			objects, additionalFields, err = function.Function.Run(t, objects, additionalFields, function.Parameters)
 
 			if err != nil {
 				return nil, nil, err
 			}
+
+			t.finish(s, before, objects)
 		}
 	}
 
-	if q.And != nil {
-		objects, additionalFields, err = t.Query(*q.And, objects, additionalFields)
+	for _, and := range q.And {
+		s := explain.Add(NewExplainStage(AndStage))
+
+		before := objects
+
+		objects, additionalFields, err = t.Query(and, objects, additionalFields, s)
 
 		if err != nil {
 			return nil, nil, err
 		}
+
+		t.finish(s, before, objects)
 	}
 
-	if q.Or != nil {
-		var next object.Objects
+	if len(q.Not) > 0 {
+		s := explain.Add(NewExplainStage(NotStage))
 
-		next, additionalFields, err = t.Query(*q.Or, andObjects, additionalFields)
+		before := objects
 
-		if err != nil {
-			return nil, additionalFields, err
+		excluded := object.Objects{}
+
+		for _, not := range q.Not {
+			var next object.Objects
+
+			next, additionalFields, err = t.Query(not, before, additionalFields, s)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			//nil means every object was matched
+			if next == nil {
+				excluded = nil
+				break
+			}
+
+			excluded = append(excluded, next...)
 		}
 
-		if next != nil {
-			objects = append(objects, next...)
-			objects = t.removeDuplicates(objects)
+		objects = t.complement(before, excluded)
+
+		t.finish(s, before, objects)
+	}
+
+	if len(q.Or) > 0 {
+		s := explain.Add(NewExplainStage(OrStage))
+
+		//a query that only consists of or does not match every object by itself
+		if !q.hasConditions() {
+			objects = object.Objects{}
 		}
+
+		for _, or := range q.Or {
+			var next object.Objects
+
+			next, additionalFields, err = t.Query(or, andObjects, additionalFields, s)
+
+			if err != nil {
+				return nil, additionalFields, err
+			}
+
+			if next == nil || objects == nil {
+				objects = nil
+			} else {
+				objects = t.removeDuplicates(append(objects, next...))
+			}
+		}
+
+		t.finish(s, andObjects, objects)
 	}
 
 	return objects, additionalFields, nil
@@ -427,82 +465,58 @@ func (t *Table) Insert(objectM map[string]json.RawMessage) error {
 		return insert()
 	}
 
-	m, err := t.JsonRawMapToMapDbType(objectM)
+	tx := t.Begin()
 
-	if err != nil {
-		return err
-	}
-
-	err = t.allFieldsHaveValues(m)
-
-	if err != nil {
-		return err
-	}
-
-	err = t.isUnique(m)
+	err := tx.insert(objectM)
 
 	if err != nil {
 		return err
 	}
 
-	t.Storage.AddObject(m)
-
-	return nil
+	return tx.Commit()
 }
 
-func (t *Table) Update(objectM map[string]json.RawMessage) error {
+func (t *Table) BulkInsert(objects []map[string]json.RawMessage) ([]error, error) {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
 
-	runMiddleware, update := UpdateMiddleware(t, objectM)
+	errs := make([]error, len(objects))
 
-	if runMiddleware {
-		return update()
-	}
+	tx := t.Begin()
 
-	foundObjectId, err := t.FindExisting(objectM)
+	for k, objectM := range objects {
+		runMiddleware, insert := InsertMiddleware(t, objectM)
 
-	if err != nil {
-		return err
-	}
-
-	o := t.Storage.GetObject(foundObjectId)
-
-	for _, f := range t.Config.Fields {
-		updatedValue, ok := objectM[f.Name]
-
-		if !ok {
+		if runMiddleware {
+			errs[k] = insert()
 			continue
 		}
 
-		v, err := idbutil.JsonRawToDBType(updatedValue, f)
-
-		if err != nil {
-			return err
-		}
-
-		o.M[f.Name] = v
+		errs[k] = tx.insert(objectM)
 	}
 
-	err = t.allFieldsHaveValues(o.M)
+	return errs, tx.Commit()
+}
 
-	if err != nil {
-		return err
-	}
+func (t *Table) Update(objectM map[string]json.RawMessage) error {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
 
-	err = t.isUnique(o.M)
+	runMiddleware, update := UpdateMiddleware(t, objectM)
 
-	if err != nil {
-		return err
+	if runMiddleware {
+		return update()
 	}
 
-	t.Storage.AddObject(o.M)
+	tx := t.Begin()
+
+	err := tx.update(objectM)
 
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 func (t *Table) Remove(object *object.Object) error {
@@ -515,88 +529,56 @@ func (t *Table) Remove(object *object.Object) error {
 		return remove()
 	}
 
-	t.Storage.RemoveObject(*object)
+	tx := t.Begin()
 
-	return nil
+	err := tx.remove(object)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (t *Table) FindExisting(object map[string]json.RawMessage) (int64, error) {
+	return t.findExisting(object, nil)
+}
+
+func (t *Table) findExisting(object map[string]json.RawMessage, skip map[int64]bool) (int64, error) {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
 
 	for fieldName, f := range t.Config.Fields {
-		value, err := idbutil.JsonRawToDBType(object[fieldName], f)
-
-		if err != nil {
-			return 0, err
+		if !f.Indexed || !f.Unique {
+			continue
 		}
 
-		if f.Indexed && f.Unique {
-			i, err := t.GetIndex(fieldName)
-
-			if err != nil {
-				t.logger.Fatal(err.Error())
-			}
-
-			indexElements := i.Equal(value)
+		raw, ok := object[fieldName]
 
-			if len(indexElements) > 0 {
-				return indexElements[0], nil
-			}
+		if !ok {
+			continue
 		}
-	}
 
-	return 0, e.CouldNotFindObjectWithAtLeastOneIndexedAndUniqueValue()
-}
+		value, err := idbutil.JsonRawToDBType(raw, f)
 
-func (t *Table) and(objects object.Objects, otherObjects object.Objects) object.Objects {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
-
-	results := object.Objects{}
-
-	for _, o := range objects {
-		for _, oo := range otherObjects {
-			if o == oo {
-				results = append(results, o)
-				break
-			}
+		if err != nil {
+			return 0, err
 		}
-	}
-
-	return results
-}
-
-func (t *Table) Sort(o object.Objects, fieldName string, additionalFields AdditionalFields, direction request.SortDirection) (object.Objects, error) {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
 
-	i, err := t.GetIndex(fieldName)
+		i, err := t.GetIndex(fieldName)
 
-	if err != nil {
-		return nil, err
-	}
-
-	sort.Slice(o, func(k, j int) bool {
-		iv := additionalFields[o[k]][fieldName]
-		jv := additionalFields[o[j]][fieldName]
-
-		if iv == nil {
-			iv = i.GetValue(o[k])
-		}
-
-		if jv == nil {
-			jv = i.GetValue(o[j])
+		if err != nil {
+			t.logger.Fatal(err.Error())
 		}
 
-		if iv == nil || jv == nil {
-			fmt.Println("F")
+		for _, id := range i.Equal(value) {
+			if !skip[id] {
+				return id, nil
+			}
 		}
+	}
 
-		return direction == request.ASC && iv.Smaller(jv)
-	})
-
-	return o, nil
+	return 0, e.CouldNotFindObjectWithAtLeastOneIndexedAndUniqueValue()
 }
 
 func (t *Table) SkipAndLimit(objects object.Objects, skip *int64, limit *int64) object.Objects {
@@ -632,55 +614,6 @@ func (t *Table) SkipAndLimit(objects object.Objects, skip *int64, limit *int64)
 	return results
 }
 
-func (t *Table) isUnique(m map[string]dbtype.DBType) error {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
-
-	for fieldName, f := range t.Config.Fields {
-		if f.Unique && f.Name != field.InternalObjectIdField {
-			i, err := t.GetIndex(fieldName)
-
-			if err != nil {
-				t.logger.Fatal(err.Error())
-			}
-
-			if len(i.Equal(m[fieldName])) > 0 {
-				return e.FoundExistingObjectWithField(fieldName)
-			}
-		}
-	}
-
-	for _, fieldNames := range t.Config.Options.CombinedUniques {
-		var first = true
-		var objects object.Objects
-
-		for _, fieldName := range fieldNames {
-			i, err := t.GetIndex(fieldName)
-
-			if err != nil {
-				t.logger.Fatal(err.Error())
-			}
-
-			if first {
-				objects = i.Equal(m[fieldName])
-				first = false
-			} else {
-				objects = t.and(objects, i.Equal(m[fieldName]))
-
-				if len(objects) == 0 {
-					break
-				}
-			}
-		}
-
-		if len(objects) != 0 {
-			return e.FoundExistingObjectWithCombinedUniques()
-		}
-	}
-
-	return nil
-}
-
 func (t *Table) allFieldsHaveValues(m map[string]dbtype.DBType) error {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
@@ -694,130 +627,19 @@ func (t *Table) allFieldsHaveValues(m map[string]dbtype.DBType) error {
 	return nil
 }
 
-func (t *Table) andEqual(andObjects object.Objects, field string, value dbtype.DBType) (object.Objects, error) {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
-
-	results := object.Objects{}
-
-	i, err := t.GetIndex(field)
-
-	if err != nil {
-		return nil, err
-	}
-
-	for _, andObject := range andObjects {
-		if i.GetValue(andObject).Equal(value) {
-			results = append(results, andObject)
-		}
-	}
-
-	return results, nil
-}
-
-func (t *Table) andNot(andObjects object.Objects, field string, value dbtype.DBType) (object.Objects, error) {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
-
-	results := object.Objects{}
-
-	i, err := t.GetIndex(field)
-
-	if err != nil {
-		return nil, err
-	}
-
-	for _, andObject := range andObjects {
-		if i.GetValue(andObject).Not(value) {
-			results = append(results, andObject)
-		}
-	}
-
-	return results, nil
-}
-
-func (t *Table) andMatch(andObjects object.Objects, field string, r regexp.Regexp) (object.Objects, error) {
+func (t *Table) andPredicate(andObjects object.Objects, i *index.Index, p *predicate) object.Objects {
 	measurementId := metrics.StartTimingMeasurement()
 	defer metrics.StopTimingMeasurement(measurementId)
 
 	results := object.Objects{}
 
-	i, err := t.GetIndex(field)
-
-	if err != nil {
-		return nil, err
-	}
-
 	for _, andObject := range andObjects {
-		if i.GetValue(andObject).Matches(r) {
+		if p.matches(i.GetValue(andObject)) {
 			results = append(results, andObject)
 		}
 	}
 
-	return results, nil
-}
-
-func (t *Table) andSmaller(andObjects object.Objects, field string, value dbtype.DBType) (object.Objects, error) {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
-
-	results := object.Objects{}
-
-	i, err := t.GetIndex(field)
-
-	if err != nil {
-		return nil, err
-	}
-
-	for _, andObject := range andObjects {
-		if i.GetValue(andObject).Smaller(value) {
-			results = append(results, andObject)
-		}
-	}
-
-	return results, nil
-}
-
-func (t *Table) andLarger(andObjects object.Objects, field string, value dbtype.DBType) (object.Objects, error) {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
-
-	results := object.Objects{}
-
-	i, err := t.GetIndex(field)
-
-	if err != nil {
-		return nil, err
-	}
-
-	for _, andObject := range andObjects {
-		if i.GetValue(andObject).Larger(value) {
-			results = append(results, andObject)
-		}
-	}
-
-	return results, nil
-}
-
-func (t *Table) andBetween(andObjects object.Objects, field string, smaller dbtype.DBType, larger dbtype.DBType) (object.Objects, error) {
-	measurementId := metrics.StartTimingMeasurement()
-	defer metrics.StopTimingMeasurement(measurementId)
-
-	results := object.Objects{}
-
-	i, err := t.GetIndex(field)
-
-	if err != nil {
-		return nil, err
-	}
-
-	for _, andObject := range andObjects {
-		if i.GetValue(andObject).Between(smaller, larger) {
-			results = append(results, andObject)
-		}
-	}
-
-	return results, nil
+	return results
 }
 
 func (t *Table) removeDuplicates(o object.Objects) object.Objects {
@@ -853,6 +675,16 @@ func (t *Table) JsonRawMapToMapDbType(m map[string]json.RawMessage) (map[string]
 		i, ok := m[f.Name]
 
 		if !ok {
+			if f.Default != nil {
+				v, err := idbutil.StringToDBType(*f.Default, f)
+
+				if err != nil {
+					return nil, err
+				}
+
+				r[f.Name] = v
+			}
+
 			continue
 		}
 
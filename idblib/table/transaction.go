@@ -0,0 +1,504 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	"github.com/lucasl0st/InfiniteDB/idblib/storage"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"sync"
+)
+
+type Transaction struct {
+	Table *Table
+
+	lock sync.Mutex
+
+	//at most one operation per existing object, new objects have no id until they are committed
+	operations []*operation
+}
+
+type operation struct {
+	eventType storage.EventType
+	id        *int64
+	m         map[string]dbtype.DBType
+
+	//fields set by updates of an existing object, they are applied to the object as it is when committing
+	changes map[string]dbtype.DBType
+}
+
+func (t *Table) Begin() *Transaction {
+	return &Transaction{
+		Table: t,
+	}
+}
+
+func (tx *Transaction) Operations() int64 {
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	return int64(len(tx.operations))
+}
+
+// Insert buffers the insert of objectM, the middleware runs the same as for Table.Insert
+func (tx *Transaction) Insert(objectM map[string]json.RawMessage) error {
+	runMiddleware, insert := InsertMiddleware(tx.Table, objectM)
+
+	if runMiddleware {
+		return insert()
+	}
+
+	return tx.insert(objectM)
+}
+
+func (tx *Transaction) insert(objectM map[string]json.RawMessage) error {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	m, err := tx.Table.JsonRawMapToMapDbType(objectM)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Table.allFieldsHaveValues(m)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.isUnique(m, nil)
+
+	if err != nil {
+		return err
+	}
+
+	tx.operations = append(tx.operations, &operation{
+		eventType: storage.EventTypeAdd,
+		m:         m,
+	})
+
+	return nil
+}
+
+// Update buffers the update of objectM, the middleware runs the same as for Table.Update
+func (tx *Transaction) Update(objectM map[string]json.RawMessage) error {
+	runMiddleware, update := UpdateMiddleware(tx.Table, objectM)
+
+	if runMiddleware {
+		return update()
+	}
+
+	return tx.update(objectM)
+}
+
+func (tx *Transaction) update(objectM map[string]json.RawMessage) error {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	op, pending, err := tx.findExisting(objectM)
+
+	if err != nil {
+		return err
+	}
+
+	m := map[string]dbtype.DBType{}
+	changes := map[string]dbtype.DBType{}
+
+	for fieldName, value := range op.m {
+		m[fieldName] = value
+	}
+
+	for _, f := range tx.Table.Config.Fields {
+		updatedValue, ok := objectM[f.Name]
+
+		if !ok || f.Name == field.InternalObjectIdField {
+			continue
+		}
+
+		v, err := idbutil.JsonRawToDBType(updatedValue, f)
+
+		if err != nil {
+			return err
+		}
+
+		m[f.Name] = v
+		changes[f.Name] = v
+	}
+
+	err = tx.Table.allFieldsHaveValues(m)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.isUnique(m, op)
+
+	if err != nil {
+		return err
+	}
+
+	op.m = m
+
+	if op.id != nil {
+		if op.changes == nil {
+			op.changes = map[string]dbtype.DBType{}
+		}
+
+		for fieldName, value := range changes {
+			op.changes[fieldName] = value
+		}
+	}
+
+	if !pending {
+		tx.operations = append(tx.operations, op)
+	}
+
+	return nil
+}
+
+// Remove buffers the removal of o, the middleware runs the same as for Table.Remove
+func (tx *Transaction) Remove(o *object.Object) error {
+	runMiddleware, remove := RemoveMiddleware(tx.Table, o)
+
+	if runMiddleware {
+		return remove()
+	}
+
+	return tx.remove(o)
+}
+
+func (tx *Transaction) remove(o *object.Object) error {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	for _, op := range tx.operations {
+		if op.id != nil && *op.id == o.Id {
+			op.eventType = storage.EventTypeRemove
+			op.m = nil
+
+			return nil
+		}
+	}
+
+	id := o.Id
+
+	tx.operations = append(tx.operations, &operation{
+		eventType: storage.EventTypeRemove,
+		id:        &id,
+	})
+
+	return nil
+}
+
+func (tx *Transaction) Commit() error {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	if len(tx.operations) == 0 {
+		return nil
+	}
+
+	s := tx.Table.Storage
+
+	_, err := s.Write(func() ([]storage.Event, error) {
+		//other writers may have changed the table since the operations were buffered
+		var events []storage.Event
+
+		for _, op := range tx.operations {
+			if op.id != nil && !s.Exists(*op.id) {
+				return nil, e.ObjectDoesNotExistAnymore(*op.id)
+			}
+
+			if op.eventType == storage.EventTypeUpdate {
+				err := tx.applyChanges(op)
+
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if op.eventType != storage.EventTypeRemove {
+				err := tx.isUnique(op.m, op)
+
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			switch op.eventType {
+			case storage.EventTypeAdd:
+				events = append(events, s.AddEvent(op.m))
+			case storage.EventTypeUpdate:
+				events = append(events, s.UpdateEvent(object.Object{Id: *op.id, M: op.m}))
+			case storage.EventTypeRemove:
+				events = append(events, s.RemoveEvent(object.Object{Id: *op.id}))
+			}
+		}
+
+		return events, nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	tx.operations = nil
+
+	return nil
+}
+
+// applyChanges sets the fields updated by op on the current version of its object,
+// fields changed by other writers since the update was buffered are kept
+func (tx *Transaction) applyChanges(op *operation) error {
+	o, err := tx.Table.Storage.GetObject(*op.id)
+
+	if err != nil {
+		return err
+	}
+
+	if o == nil {
+		return e.ObjectDoesNotExistAnymore(*op.id)
+	}
+
+	m := make(map[string]dbtype.DBType, len(o.M))
+
+	for fieldName, value := range o.M {
+		m[fieldName] = value
+	}
+
+	for fieldName, value := range op.changes {
+		m[fieldName] = value
+	}
+
+	err = tx.Table.allFieldsHaveValues(m)
+
+	if err != nil {
+		return err
+	}
+
+	op.m = m
+
+	return nil
+}
+
+func (tx *Transaction) Rollback() {
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	tx.operations = nil
+}
+
+func (tx *Transaction) findExisting(objectM map[string]json.RawMessage) (*operation, bool, error) {
+	for fieldName, f := range tx.Table.Config.Fields {
+		if !f.Indexed || !f.Unique {
+			continue
+		}
+
+		raw, ok := objectM[fieldName]
+
+		if !ok {
+			continue
+		}
+
+		value, err := idbutil.JsonRawToDBType(raw, f)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, op := range tx.operations {
+			if op.eventType == storage.EventTypeRemove {
+				continue
+			}
+
+			if tx.valueOf(op, fieldName) != nil && tx.valueOf(op, fieldName).Equal(value) {
+				return op, true, nil
+			}
+		}
+	}
+
+	id, err := tx.Table.findExisting(objectM, tx.touched())
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	o, err := tx.Table.Storage.GetObject(id)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if o == nil {
+		return nil, false, e.ObjectDoesNotExistAnymore(id)
+	}
+
+	return &operation{
+		eventType: storage.EventTypeUpdate,
+		id:        &o.Id,
+		m:         o.M,
+	}, false, nil
+}
+
+func (tx *Transaction) isUnique(m map[string]dbtype.DBType, self *operation) error {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	touched := tx.touched()
+
+	if self != nil && self.id != nil {
+		touched[*self.id] = true
+	}
+
+	for fieldName, f := range tx.Table.Config.Fields {
+		if !f.Unique || f.Name == field.InternalObjectIdField || m[fieldName] == nil {
+			continue
+		}
+
+		i, err := tx.Table.GetIndex(fieldName)
+
+		if err != nil {
+			tx.Table.logger.Fatal(err.Error())
+		}
+
+		if len(tx.committed(i.Equal(m[fieldName]), touched)) > 0 {
+			return e.FoundExistingObjectWithField(fieldName)
+		}
+
+		for _, op := range tx.pending(self) {
+			if op.m[fieldName] != nil && op.m[fieldName].Equal(m[fieldName]) {
+				return e.FoundExistingObjectWithField(fieldName)
+			}
+		}
+	}
+
+	for _, fieldNames := range tx.Table.Config.Options.CombinedUniques {
+		if tx.hasCommittedCombination(m, fieldNames, touched) || tx.hasPendingCombination(m, fieldNames, self) {
+			return e.FoundExistingObjectWithCombinedUniques()
+		}
+	}
+
+	return nil
+}
+
+func (tx *Transaction) hasCommittedCombination(m map[string]dbtype.DBType, fieldNames []string, touched map[int64]bool) bool {
+	var objects object.Objects
+
+	for k, fieldName := range fieldNames {
+		if m[fieldName] == nil {
+			return false
+		}
+
+		i, err := tx.Table.GetIndex(fieldName)
+
+		if err != nil {
+			tx.Table.logger.Fatal(err.Error())
+		}
+
+		if k == 0 {
+			objects = tx.committed(i.Equal(m[fieldName]), touched)
+		} else {
+			objects = intersect(objects, i.Equal(m[fieldName]))
+		}
+
+		if len(objects) == 0 {
+			return false
+		}
+	}
+
+	return len(objects) > 0
+}
+
+func (tx *Transaction) hasPendingCombination(m map[string]dbtype.DBType, fieldNames []string, self *operation) bool {
+	for _, op := range tx.pending(self) {
+		matches := len(fieldNames) > 0
+
+		for _, fieldName := range fieldNames {
+			if m[fieldName] == nil || op.m[fieldName] == nil || !op.m[fieldName].Equal(m[fieldName]) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (tx *Transaction) touched() map[int64]bool {
+	//existing objects which are updated or removed by this transaction, their indexed values are outdated
+	touched := map[int64]bool{}
+
+	for _, op := range tx.operations {
+		if op.id != nil {
+			touched[*op.id] = true
+		}
+	}
+
+	return touched
+}
+
+func (tx *Transaction) committed(ids []int64, touched map[int64]bool) object.Objects {
+	var results object.Objects
+
+	for _, id := range ids {
+		if !touched[id] {
+			results = append(results, id)
+		}
+	}
+
+	return results
+}
+
+func (tx *Transaction) pending(self *operation) []*operation {
+	var results []*operation
+
+	for _, op := range tx.operations {
+		if op != self && op.eventType != storage.EventTypeRemove {
+			results = append(results, op)
+		}
+	}
+
+	return results
+}
+
+func (tx *Transaction) valueOf(op *operation, fieldName string) dbtype.DBType {
+	if fieldName != field.InternalObjectIdField {
+		return op.m[fieldName]
+	}
+
+	if op.id == nil {
+		return nil
+	}
+
+	n, err := dbtype.NumberFromInt64(*op.id)
+
+	if err != nil {
+		tx.Table.logger.Fatal(err.Error())
+	}
+
+	return n
+}
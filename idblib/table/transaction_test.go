@@ -0,0 +1,228 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	"github.com/lucasl0st/InfiniteDB/models/metric"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type receiver struct{}
+
+func (receiver) DatabaseMetrics(string, metric.DatabaseMetrics) {}
+func (receiver) PerformanceMetrics(metric.PerformanceMetrics)   {}
+func (receiver) MemStatsMetrics(metric.MemStatsMetrics)         {}
+
+var testMetrics = func() *metrics.Metrics {
+	var r metric.Receiver = receiver{}
+
+	return metrics.New(&r)
+}()
+
+func openTestTable(t *testing.T, path string) *Table {
+	config := field.TableConfig{
+		Fields: map[string]field.Field{
+			"name":  {Name: "name", Type: dbtype.TEXT, Indexed: true, Unique: true},
+			"value": {Name: "value", Type: dbtype.TEXT, Null: true},
+		},
+	}
+
+	table, err := NewTable("database", "table", path, config, log.New(io.Discard, "", 0), testMetrics, 100, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(table.Kill)
+
+	return table
+}
+
+func newTestTable(t *testing.T) (*Table, string) {
+	path := t.TempDir() + "/"
+
+	err := os.Mkdir(path+"table", os.ModePerm)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return openTestTable(t, path), path
+}
+
+func testObject(name string, value string) map[string]json.RawMessage {
+	return map[string]json.RawMessage{
+		"name":  json.RawMessage(`"` + name + `"`),
+		"value": json.RawMessage(`"` + value + `"`),
+	}
+}
+
+// storedObjects reads the table from its files again and returns name -> value of all objects
+func storedObjects(t *testing.T, path string) map[string]string {
+	table := openTestTable(t, path)
+
+	objects, err := table.Storage.GetObjects(table.Storage.Ids())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := map[string]string{}
+
+	for _, o := range objects {
+		results[o.M["name"].ToString()] = o.M["value"].ToString()
+	}
+
+	return results
+}
+
+func assertStored(t *testing.T, path string, expected map[string]string) {
+	t.Helper()
+
+	stored := storedObjects(t, path)
+
+	if !reflect.DeepEqual(expected, stored) {
+		t.Errorf("expected %v, got %v", expected, stored)
+	}
+}
+
+func TestCommitIsAllOrNothing(t *testing.T) {
+	table, path := newTestTable(t)
+
+	err := table.Insert(testObject("a", "1"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := table.Begin()
+
+	err = tx.Update(testObject("a", "2"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"b", "c"} {
+		err = tx.Insert(testObject(name, "1"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//written by another writer after the transaction buffered c
+	err = table.Insert(testObject("c", "2"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tx.Commit()
+
+	if err == nil {
+		t.Fatal("expected the commit to fail because c already exists")
+	}
+
+	assertStored(t, path, map[string]string{"a": "1", "c": "2"})
+}
+
+func TestRollbackWritesNothing(t *testing.T) {
+	table, path := newTestTable(t)
+
+	err := table.Insert(testObject("a", "1"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := os.ReadFile(path + "table/objects.idb")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := table.Begin()
+
+	err = tx.Insert(testObject("b", "1"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tx.Update(testObject("a", "2"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx.Rollback()
+
+	err = tx.Commit()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := os.ReadFile(path + "table/objects.idb")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(before) != string(after) {
+		t.Errorf("expected the rolled back transaction to write nothing, got %s", after[len(before):])
+	}
+
+	assertStored(t, path, map[string]string{"a": "1"})
+}
+
+func TestUniqueConflictInTransaction(t *testing.T) {
+	table, path := newTestTable(t)
+
+	err := table.Insert(testObject("a", "1"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := table.Begin()
+
+	err = tx.Insert(testObject("b", "1"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		objectM map[string]json.RawMessage
+	}{
+		{name: "committed", objectM: testObject("a", "2")},
+		{name: "pending", objectM: testObject("b", "2")},
+	}
+
+	for _, tc := range cases {
+		if tx.Insert(tc.objectM) == nil {
+			t.Errorf("%s: expected the insert to be rejected", tc.name)
+		}
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStored(t, path, map[string]string{"a": "1", "b": "1"})
+}
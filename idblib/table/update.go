@@ -0,0 +1,232 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	"github.com/lucasl0st/InfiniteDB/idblib/storage"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+	"github.com/lucasl0st/InfiniteDB/util"
+)
+
+// fieldUpdate is a FieldUpdate with its field and the value converted to the type of the field
+type fieldUpdate struct {
+	FieldUpdate
+
+	value dbtype.DBType
+	text  string
+}
+
+// UpdateWhere applies updates to all objects returned by find, it returns the number of objects found and
+// the number of objects whose values changed. The objects are found, checked and written at once while holding the lock of the table file
+func (t *Table) UpdateWhere(find func() (object.Objects, error), updates []FieldUpdate) (int64, int64, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	runMiddleware, updateWhere := UpdateWhereMiddleware(t, updates)
+
+	if runMiddleware {
+		return updateWhere()
+	}
+
+	fieldUpdates, err := t.fieldUpdates(updates)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var matched int64
+
+	tx := t.Begin()
+
+	_, err = t.Storage.Write(func() ([]storage.Event, error) {
+		ids, err := find()
+
+		if err != nil {
+			return nil, err
+		}
+
+		objects, err := t.Storage.GetObjects(ids)
+
+		if err != nil {
+			return nil, err
+		}
+		matched = int64(len(objects))
+
+		for _, o := range objects {
+			m, changed, err := applyUpdates(o.M, fieldUpdates)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !changed {
+				continue
+			}
+
+			err = t.allFieldsHaveValues(m)
+
+			if err != nil {
+				return nil, err
+			}
+
+			id := o.Id
+
+			tx.operations = append(tx.operations, &operation{
+				eventType: storage.EventTypeUpdate,
+				id:        &id,
+				m:         m,
+			})
+		}
+
+		//checked after all objects were changed, a unique value may be moved from one object to another
+		var events []storage.Event
+
+		for _, op := range tx.operations {
+			err := tx.isUnique(op.m, op)
+
+			if err != nil {
+				return nil, err
+			}
+
+			events = append(events, t.Storage.UpdateEvent(object.Object{Id: *op.id, M: op.m}))
+		}
+
+		return events, nil
+	})
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return matched, int64(len(tx.operations)), nil
+}
+
+// fieldUpdates checks the fields of the updates and converts their values
+func (t *Table) fieldUpdates(updates []FieldUpdate) ([]fieldUpdate, error) {
+	var results []fieldUpdate
+
+	for _, update := range updates {
+		f, ok := t.Config.Fields[update.Field]
+
+		if !ok {
+			return nil, e.CannotFindField(update.Field)
+		}
+
+		if f.Name == field.InternalObjectIdField {
+			return nil, e.FieldCannotBeUpdated(f.Name)
+		}
+
+		u := fieldUpdate{
+			FieldUpdate: update,
+		}
+
+		switch update.Operator {
+		case request.SET, request.UNSET:
+			v, err := idbutil.JsonRawToDBType(update.Value, f)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if v.IsNull() && !f.Null {
+				return nil, e.ObjectDoesNotHaveValueForField(f.Name)
+			}
+
+			u.value = v
+		case request.INC, request.MUL:
+			if f.Type != dbtype.NUMBER {
+				return nil, e.UpdateOperatorNeedsType(update.Operator, f.Name, string(dbtype.NUMBER))
+			}
+
+			v, err := idbutil.JsonRawToDBType(update.Value, f)
+
+			if err != nil {
+				return nil, err
+			}
+
+			u.value = v
+		case request.CONCAT:
+			if f.Type != dbtype.TEXT {
+				return nil, e.UpdateOperatorNeedsType(update.Operator, f.Name, string(dbtype.TEXT))
+			}
+
+			s, err := util.JsonRawToString(update.Value)
+
+			if err != nil || s == nil {
+				return nil, e.IsNotAString(f.Name)
+			}
+
+			u.text = *s
+		default:
+			return nil, e.NotAValidUpdateOperator(string(update.Operator))
+		}
+
+		results = append(results, u)
+	}
+
+	return results, nil
+}
+
+// applyUpdates returns a copy of m with the updates applied and if any value changed
+func applyUpdates(m map[string]dbtype.DBType, updates []fieldUpdate) (map[string]dbtype.DBType, bool, error) {
+	results := make(map[string]dbtype.DBType, len(m))
+
+	for fieldName, value := range m {
+		results[fieldName] = value
+	}
+
+	changed := false
+
+	for _, update := range updates {
+		current := m[update.Field]
+
+		var next dbtype.DBType
+
+		switch update.Operator {
+		case request.SET, request.UNSET:
+			next = update.value
+		case request.INC, request.MUL:
+			n := dbtype.NumberFromNull()
+
+			if current != nil {
+				n = current.(dbtype.Number)
+			}
+
+			var err error
+
+			if update.Operator == request.INC {
+				next, err = n.Add(update.value.(dbtype.Number))
+			} else {
+				next, err = n.Mul(update.value.(dbtype.Number))
+			}
+
+			if err != nil {
+				return nil, false, err
+			}
+		case request.CONCAT:
+			s := dbtype.TextFromNull()
+
+			if current != nil {
+				s = current.(dbtype.Text)
+			}
+
+			next = s.Concat(update.text)
+		}
+
+		if current == nil || current.IsNull() != next.IsNull() || current.ToString() != next.ToString() {
+			changed = true
+		}
+
+		results[update.Field] = next
+	}
+
+	return results, changed, nil
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package table
+
+import (
+	"encoding/json"
+	"github.com/lucasl0st/InfiniteDB/idblib/dbtype"
+	"github.com/lucasl0st/InfiniteDB/idblib/field"
+	"github.com/lucasl0st/InfiniteDB/idblib/metrics"
+	"github.com/lucasl0st/InfiniteDB/idblib/object"
+	"github.com/lucasl0st/InfiniteDB/idblib/storage"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
+	e "github.com/lucasl0st/InfiniteDB/models/errors"
+)
+
+// Upsert updates the object with the same unique or combined unique values as objectM or inserts objectM if there is none,
+// it returns true if the object was inserted. The object is looked up and written while holding the lock of the table file,
+// so other writers cannot insert or change it in between
+func (t *Table) Upsert(objectM map[string]json.RawMessage) (bool, error) {
+	measurementId := metrics.StartTimingMeasurement()
+	defer metrics.StopTimingMeasurement(measurementId)
+
+	runMiddleware, upsert := UpsertMiddleware(t, objectM)
+
+	if runMiddleware {
+		return upsert()
+	}
+
+	inserted := false
+
+	_, err := t.Storage.Write(func() ([]storage.Event, error) {
+		id, found, err := t.findUpsertTarget(objectM)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tx := t.Begin()
+
+		if !found {
+			m, err := t.JsonRawMapToMapDbType(objectM)
+
+			if err != nil {
+				return nil, err
+			}
+
+			err = t.allFieldsHaveValues(m)
+
+			if err != nil {
+				return nil, err
+			}
+
+			err = tx.isUnique(m, nil)
+
+			if err != nil {
+				return nil, err
+			}
+
+			inserted = true
+
+			return []storage.Event{t.Storage.AddEvent(m)}, nil
+		}
+
+		o, err := t.Storage.GetObject(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if o == nil {
+			return nil, e.ObjectDoesNotExistAnymore(id)
+		}
+
+		m := map[string]dbtype.DBType{}
+
+		for fieldName, value := range o.M {
+			m[fieldName] = value
+		}
+
+		for _, f := range t.Config.Fields {
+			updatedValue, ok := objectM[f.Name]
+
+			if !ok || f.Name == field.InternalObjectIdField {
+				continue
+			}
+
+			v, err := idbutil.JsonRawToDBType(updatedValue, f)
+
+			if err != nil {
+				return nil, err
+			}
+
+			m[f.Name] = v
+		}
+
+		err = t.allFieldsHaveValues(m)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = tx.isUnique(m, &operation{eventType: storage.EventTypeUpdate, id: &id})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []storage.Event{t.Storage.UpdateEvent(object.Object{Id: id, M: m})}, nil
+	})
+
+	return inserted, err
+}
+
+// findUpsertTarget returns the object having the unique values of objectM or all values of one of the combined uniques,
+// objectM must have at least one of them and all of them have to refer to the same object
+func (t *Table) findUpsertTarget(objectM map[string]json.RawMessage) (int64, bool, error) {
+	ids := map[int64]bool{}
+	resolvable := false
+
+	for fieldName, f := range t.Config.Fields {
+		if !f.Unique || fieldName == field.InternalObjectIdField {
+			continue
+		}
+
+		value, err := t.upsertValue(objectM, f)
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		if value == nil {
+			continue
+		}
+
+		resolvable = true
+
+		i, err := t.GetIndex(fieldName)
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		for _, id := range i.Equal(value) {
+			ids[id] = true
+		}
+	}
+
+	for _, fieldNames := range t.Config.Options.CombinedUniques {
+		var objects object.Objects
+
+		complete := len(fieldNames) > 0
+
+		for k, fieldName := range fieldNames {
+			value, err := t.upsertValue(objectM, t.Config.Fields[fieldName])
+
+			if err != nil {
+				return 0, false, err
+			}
+
+			if value == nil {
+				complete = false
+				break
+			}
+
+			i, err := t.GetIndex(fieldName)
+
+			if err != nil {
+				return 0, false, err
+			}
+
+			if k == 0 {
+				objects = i.Equal(value)
+			} else {
+				objects = intersect(objects, i.Equal(value))
+			}
+		}
+
+		if !complete {
+			continue
+		}
+
+		resolvable = true
+
+		for _, id := range objects {
+			ids[id] = true
+		}
+	}
+
+	if !resolvable {
+		return 0, false, e.UpsertNeedsUniqueValue()
+	}
+
+	if len(ids) > 1 {
+		return 0, false, e.UpsertMatchesMoreThanOneObject()
+	}
+
+	for id := range ids {
+		return id, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// upsertValue returns the value of f in objectM, nil if objectM has no value or null for it
+func (t *Table) upsertValue(objectM map[string]json.RawMessage, f field.Field) (dbtype.DBType, error) {
+	raw, ok := objectM[f.Name]
+
+	if !ok {
+		return nil, nil
+	}
+
+	value, err := idbutil.JsonRawToDBType(raw, f)
+
+	if err != nil || value.IsNull() {
+		return nil, err
+	}
+
+	return value, nil
+}
@@ -56,6 +56,11 @@ func StringToDBType(s string, f field.Field) (dbtype.DBType, error) {
 	case dbtype.TEXT:
 		return dbtype.TextFromString(s), nil
 	case dbtype.NUMBER:
+		//null numbers are stored as "null"
+		if s == "null" {
+			return dbtype.NumberFromNull(), nil
+		}
+
 		return dbtype.NumberFromString(s)
 	case dbtype.BOOL:
 		return dbtype.BoolFromString(s), nil
@@ -63,3 +68,16 @@ func StringToDBType(s string, f field.Field) (dbtype.DBType, error) {
 
 	return nil, e.UnknownDBTypeError()
 }
+
+func NullDBType(f field.Field) (dbtype.DBType, error) {
+	switch f.Type {
+	case dbtype.TEXT:
+		return dbtype.TextFromNull(), nil
+	case dbtype.NUMBER:
+		return dbtype.NumberFromNull(), nil
+	case dbtype.BOOL:
+		return dbtype.BoolFromNull(), nil
+	}
+
+	return nil, e.UnknownDBTypeError()
+}
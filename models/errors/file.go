@@ -4,8 +4,23 @@
 
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 func DontHaveLock() error {
 	return errors.New("don't have lock on file")
 }
+
+func IndexCheckpointIsCorrupted() error {
+	return errors.New("index checkpoint is corrupted")
+}
+
+func IndexCheckpointIsStale() error {
+	return errors.New("index checkpoint does not match the table anymore")
+}
+
+func ObjectCannotBeRead(id int64) error {
+	return fmt.Errorf("cannot read object %d from the table file", id)
+}
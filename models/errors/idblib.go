@@ -4,7 +4,10 @@
 
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 func DatabaseAlreadyExists() error {
 	return errors.New("database already exists")
@@ -22,6 +25,26 @@ func TableDoesNotExist() error {
 	return errors.New("table does not exist")
 }
 
+func TableWasClosed() error {
+	return errors.New("table was closed because it was changed or deleted, retry the request")
+}
+
 func IdbNotReady() error {
 	return errors.New("idb is not ready")
 }
+
+func TransactionDoesNotExist() error {
+	return errors.New("transaction does not exist")
+}
+
+func TransactionIsOnOtherTable(name string, tableName string) error {
+	return fmt.Errorf("transaction was begun on table %s in database %s", tableName, name)
+}
+
+func CursorDoesNotExist() error {
+	return errors.New("cursor does not exist or has expired")
+}
+
+func StreamWasClosed() error {
+	return errors.New("stream was closed before all results were sent")
+}
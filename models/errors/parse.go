@@ -9,10 +9,6 @@ import (
 	"fmt"
 )
 
-func CannotHaveAndANDOrInOneQuery() error {
-	return errors.New("cannot have AND and OR in one query")
-}
-
 func NotAValidFunction() error {
 	return errors.New("not a valid function")
 }
@@ -40,3 +36,91 @@ func IsNotANumber(param string) error {
 func OnlyValueAllOrAny() error {
 	return errors.New("can only have value, all or any, not in combination")
 }
+
+func NotAValidAlterTableAction(action string) error {
+	return errors.New(fmt.Sprintf("%s is not a valid alter table action", action))
+}
+
+func OnlyFieldsOrExclude() error {
+	return errors.New("can only have fields or exclude, not in combination")
+}
+
+func NotAValidAccumulator(accumulator string) error {
+	return errors.New(fmt.Sprintf("%s is not a valid accumulator", accumulator))
+}
+
+func AccumulatorNeedsField(accumulator string) error {
+	return errors.New(fmt.Sprintf("accumulator %s needs a field", accumulator))
+}
+
+func AggregateNameIsUsedMoreThanOnce(name string) error {
+	return errors.New(fmt.Sprintf("%s is used more than once in aggregate", name))
+}
+
+func NotAValidCursor() error {
+	return errors.New("not a valid cursor")
+}
+
+func CursorDoesNotMatchSort() error {
+	return errors.New("cursor does not match the sort of the request")
+}
+
+func CannotUseCursorWithAggregate() error {
+	return errors.New("cannot use a cursor on aggregated rows")
+}
+
+func CannotSkipWithCursor() error {
+	return errors.New("cannot skip objects when paginating with a cursor")
+}
+
+func SortKeyNeedsField() error {
+	return errors.New("sort key needs a field")
+}
+
+func NotAValidSortDirection(direction string) error {
+	return errors.New(fmt.Sprintf("%s is not a valid sort direction", direction))
+}
+
+func NotAValidNullsOrder(nulls string) error {
+	return errors.New(fmt.Sprintf("%s is not a valid order for null values", nulls))
+}
+
+func SortFieldIsUsedMoreThanOnce(fieldName string) error {
+	return errors.New(fmt.Sprintf("sort field %s is used more than once", fieldName))
+}
+
+func ImplementNeedsTableAndFields() error {
+	return errors.New("implement needs the table and field to implement from and the field to match")
+}
+
+func CannotHaveOnAndFieldsInOneImplement() error {
+	return errors.New("implement cannot have on and from.field or field at the same time")
+}
+
+func NotAValidImplementType(implementType string) error {
+	return errors.New(fmt.Sprintf("%s is not a valid implement type", implementType))
+}
+
+func UpdateNeedsQuery() error {
+	return errors.New("update needs a query selecting the objects")
+}
+
+func UpdateNeedsFields() error {
+	return errors.New("update needs at least one field to change")
+}
+
+func NotAValidUpdateOperator(operator string) error {
+	return errors.New(fmt.Sprintf("%s is not a valid update operator", operator))
+}
+
+func ValueForUpdateOperatorMustBeObject(operator string) error {
+	return errors.New(fmt.Sprintf("value must be an object of fields for update operator %s", operator))
+}
+
+func ValueForUpdateOperatorMustBeArray(operator string) error {
+	return errors.New(fmt.Sprintf("value must be an array of fields for update operator %s", operator))
+}
+
+func FieldIsUpdatedMoreThanOnce(fieldName string) error {
+	return errors.New(fmt.Sprintf("field %s is updated more than once", fieldName))
+}
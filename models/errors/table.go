@@ -30,6 +30,22 @@ func CouldNotFindObjectWithAtLeastOneIndexedAndUniqueValue() error {
 	return errors.New("could not find object with at least one indexed and unique value")
 }
 
+func UpsertNeedsUniqueValue() error {
+	return errors.New("upsert needs the value of a unique field or all values of a combined unique")
+}
+
+func UpsertMatchesMoreThanOneObject() error {
+	return errors.New("the unique values of the upserted object belong to more than one existing object")
+}
+
+func FieldCannotBeUpdated(fieldName string) error {
+	return errors.New(fmt.Sprintf("field %s cannot be updated", fieldName))
+}
+
+func UpdateOperatorNeedsType(operator request.UpdateOperator, fieldName string, t string) error {
+	return errors.New(fmt.Sprintf("update operator %s needs a %s field, %s is not a %s", operator, t, fieldName, t))
+}
+
 func FoundExistingObjectWithField(fieldName string) error {
 	return errors.New(fmt.Sprintf("found existing object with field %s", fieldName))
 }
@@ -45,3 +61,67 @@ func ObjectDoesNotHaveValueForField(fieldName string) error {
 func ValueForOperatorMustBeString(operator request.Operator) error {
 	return errors.New(fmt.Sprintf("value must be string for operator %s", operator))
 }
+
+func ValueForOperatorMustBeArray(operator request.Operator) error {
+	return errors.New(fmt.Sprintf("value must be an array for operator %s", operator))
+}
+
+func OperatorNeedsTextField(operator request.Operator, fieldName string) error {
+	return errors.New(fmt.Sprintf("operator %s needs a text field, %s is not a text", operator, fieldName))
+}
+
+func OperatorDoesNotTakeValue(operator request.Operator) error {
+	return errors.New(fmt.Sprintf("operator %s does not take a value", operator))
+}
+
+func OperatorCannotBeCaseInsensitive(operator request.Operator) error {
+	return errors.New(fmt.Sprintf("operator %s cannot be case-insensitive", operator))
+}
+
+func ObjectDoesNotExistAnymore(id int64) error {
+	return errors.New(fmt.Sprintf("object %d does not exist anymore", id))
+}
+
+func FieldIsAlreadyIndexed(fieldName string) error {
+	return errors.New(fmt.Sprintf("field %s is already indexed", fieldName))
+}
+
+func FieldIsNotIndexed(fieldName string) error {
+	return errors.New(fmt.Sprintf("field %s is not indexed", fieldName))
+}
+
+func IndexIsAlreadyBeingBuilt(fieldName string) error {
+	return errors.New(fmt.Sprintf("index of field %s is already being built", fieldName))
+}
+
+func CannotDropIndexOfUniqueField(fieldName string) error {
+	return errors.New(fmt.Sprintf("cannot drop index of field %s, unique fields must be indexed", fieldName))
+}
+
+func FieldAlreadyExists(fieldName string) error {
+	return errors.New(fmt.Sprintf("field %s already exists", fieldName))
+}
+
+func FieldIsChangedMoreThanOnce(fieldName string) error {
+	return errors.New(fmt.Sprintf("field %s is changed more than once", fieldName))
+}
+
+func FieldIsPartOfCombinedUnique(fieldName string) error {
+	return errors.New(fmt.Sprintf("field %s is part of a combined unique", fieldName))
+}
+
+func CannotConvertField(fieldName string, from string, to string) error {
+	return errors.New(fmt.Sprintf("cannot convert field %s from %s to %s", fieldName, from, to))
+}
+
+func CannotConvertValueOfField(fieldName string, value string) error {
+	return errors.New(fmt.Sprintf("cannot convert value %s of field %s", value, fieldName))
+}
+
+func ScanLimitExceeded(fieldName string, objects int, limit uint) error {
+	return errors.New(fmt.Sprintf("query on field %s would scan %d objects, the scan limit is %d, index the field or narrow the query", fieldName, objects, limit))
+}
+
+func AccumulatorNeedsNumberField(accumulator string, fieldName string) error {
+	return errors.New(fmt.Sprintf("accumulator %s needs a number field, %s is not a number", accumulator, fieldName))
+}
@@ -15,8 +15,23 @@ const GetDatabaseTableMethod ServerMethod = "getDatabaseTable"
 const CreateTableInDatabaseMethod ServerMethod = "createTableInDatabase"
 const DeleteTableInDatabaseMethod ServerMethod = "deleteTableInDatabase"
 const GetFromDatabaseTableMethod ServerMethod = "getFromDatabaseTable"
+const CountFromDatabaseTableMethod ServerMethod = "countFromDatabaseTable"
+const ExistsInDatabaseTableMethod ServerMethod = "existsInDatabaseTable"
+const OpenCursorMethod ServerMethod = "openCursor"
+const FetchCursorMethod ServerMethod = "fetchCursor"
+const CloseCursorMethod ServerMethod = "closeCursor"
 const InsertToDatabaseTableMethod ServerMethod = "insertToDatabaseTable"
+const BulkInsertToDatabaseTableMethod ServerMethod = "bulkInsertToDatabaseTable"
 const RemoveFromDatabaseTableMethod ServerMethod = "removeFromDatabaseTable"
 const UpdateInDatabaseTableMethod ServerMethod = "updateInDatabaseTable"
+const UpsertToDatabaseTableMethod ServerMethod = "upsertToDatabaseTable"
+const UpdateWhereMethod ServerMethod = "updateWhere"
+const CompactDatabaseTableMethod ServerMethod = "compactDatabaseTable"
+const AlterTableMethod ServerMethod = "alterTable"
+const CreateIndexMethod ServerMethod = "createIndex"
+const DropIndexMethod ServerMethod = "dropIndex"
+const BeginTransactionMethod ServerMethod = "beginTransaction"
+const CommitTransactionMethod ServerMethod = "commit"
+const RollbackTransactionMethod ServerMethod = "rollback"
 const SubscribeToMetricUpdates ServerMethod = "subscribeToMetricUpdates"
 const UnsubscribeFromMetricUpdates ServerMethod = "unsubscribeFromMetricUpdates"
@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package request
+
+type AccumulatorType string
+
+const (
+	COUNT          AccumulatorType = "count"
+	SUM            AccumulatorType = "sum"
+	AVG            AccumulatorType = "avg"
+	MIN            AccumulatorType = "min"
+	MAX            AccumulatorType = "max"
+	COUNT_DISTINCT AccumulatorType = "countDistinct"
+)
+
+type Aggregate struct {
+	GroupBy      []string      `json:"groupBy"`
+	Accumulators []Accumulator `json:"accumulators"`
+}
+
+type Accumulator struct {
+	Accumulator AccumulatorType `json:"accumulator"`
+	Field       string          `json:"field"`
+	As          string          `json:"as"`
+}
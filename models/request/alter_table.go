@@ -0,0 +1,25 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package request
+
+import "encoding/json"
+
+type AlterTableAction string
+
+const (
+	ADD_FIELD   AlterTableAction = "addField"
+	DROP_FIELD  AlterTableAction = "dropField"
+	ALTER_FIELD AlterTableAction = "alterField"
+)
+
+type AlterTableChange struct {
+	Action    AlterTableAction `json:"action"`
+	FieldName string           `json:"fieldName"`
+	Type      *string          `json:"type,omitempty"`
+	Indexed   *bool            `json:"indexed,omitempty"`
+	Unique    *bool            `json:"unique,omitempty"`
+	Null      *bool            `json:"null,omitempty"`
+	Default   json.RawMessage  `json:"default,omitempty"`
+}
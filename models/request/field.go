@@ -4,9 +4,12 @@
 
 package request
 
+import "encoding/json"
+
 type Field struct {
-	Type    string `json:"type"`
-	Indexed *bool  `json:"indexed"`
-	Unique  *bool  `json:"unique"`
-	Null    *bool  `json:"null"`
+	Type    string          `json:"type"`
+	Indexed *bool           `json:"indexed"`
+	Unique  *bool           `json:"unique"`
+	Null    *bool           `json:"null"`
+	Default json.RawMessage `json:"default,omitempty"`
 }
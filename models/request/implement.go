@@ -9,9 +9,35 @@ type Implement struct {
 	Field      string        `json:"field"`
 	As         *string       `json:"as"`
 	ForceArray *bool         `json:"forceArray"`
+	//pairs of fields that all have to match, instead of from.field and field
+	On   []ImplementOn `json:"on,omitempty"`
+	Type ImplementType `json:"type,omitempty"`
+	//only the objects of the other table matching the query are implemented
+	Query  *Query   `json:"query,omitempty"`
+	Fields []string `json:"fields,omitempty"`
+	Sort   SortKeys `json:"sort,omitempty"`
+	//maximum number of implemented objects per object
+	Limit     *int64      `json:"limit,omitempty"`
+	Implement []Implement `json:"implement,omitempty"`
 }
 
 type ImplementFrom struct {
 	Table string `json:"table"`
 	Field string `json:"field"`
 }
+
+type ImplementOn struct {
+	Field     string `json:"field"`
+	FromField string `json:"fromField"`
+}
+
+type ImplementType string
+
+const (
+	// LEFT returns all objects, objects without a match are returned without the implemented field
+	LEFT ImplementType = "left"
+	// INNER only returns objects with a match
+	INNER ImplementType = "inner"
+	// ANTI only returns objects without a match
+	ANTI ImplementType = "anti"
+)
@@ -7,10 +7,24 @@ package request
 type Operator string
 
 const (
-	EQUALS  Operator = "="
-	NOT     Operator = "!="
-	MATCH   Operator = "match"
-	LARGER  Operator = ">"
-	SMALLER Operator = "<"
-	BETWEEN Operator = "><"
+	EQUALS           Operator = "="
+	NOT              Operator = "!="
+	MATCH            Operator = "match"
+	LARGER           Operator = ">"
+	SMALLER          Operator = "<"
+	BETWEEN          Operator = "><"
+	LARGER_OR_EQUAL  Operator = ">="
+	SMALLER_OR_EQUAL Operator = "<="
+	IN               Operator = "in"
+	NOT_IN           Operator = "notIn"
+	IS_NULL          Operator = "isNull"
+	IS_NOT_NULL      Operator = "isNotNull"
+	STARTS_WITH      Operator = "startsWith"
+	ENDS_WITH        Operator = "endsWith"
+	CONTAINS         Operator = "contains"
 )
+
+// IsTextOperator returns if the operator compares text and can be case-insensitive
+func (o Operator) IsTextOperator() bool {
+	return o == STARTS_WITH || o == ENDS_WITH || o == CONTAINS
+}
@@ -4,9 +4,90 @@
 
 package request
 
+import (
+	"bytes"
+	"encoding/json"
+)
+
 type Query struct {
-	Where     *Where     `json:"where"`
-	Functions []Function `json:"functions"`
-	And       *Query     `json:"and"`
-	Or        *Query     `json:"or"`
+	Where      *Where     `json:"where"`
+	Functions  []Function `json:"functions"`
+	AndQueries Queries    `json:"and"`
+	OrQueries  Queries    `json:"or"`
+	Not        Queries    `json:"not"`
+	// Deprecated: use AndQueries, And is only read by Ands and never set when unmarshalling
+	And *Query `json:"-"`
+	// Deprecated: use OrQueries, Or is only read by Ors and never set when unmarshalling
+	Or *Query `json:"-"`
+}
+
+// Queries is a list of nested queries, a single query can also be sent as object instead of an array
+type Queries []Query
+
+// Ands returns the deprecated And followed by AndQueries
+func (q Query) Ands() Queries {
+	return nested(q.And, q.AndQueries)
+}
+
+// Ors returns the deprecated Or followed by OrQueries
+func (q Query) Ors() Queries {
+	return nested(q.Or, q.OrQueries)
+}
+
+func nested(query *Query, queries Queries) Queries {
+	if query == nil {
+		return queries
+	}
+
+	return append(Queries{*query}, queries...)
+}
+
+// MarshalJSON writes the deprecated And and Or together with AndQueries and OrQueries
+func (q Query) MarshalJSON() ([]byte, error) {
+	type plain Query
+
+	p := plain(q)
+	p.AndQueries = q.Ands()
+	p.OrQueries = q.Ors()
+
+	return json.Marshal(p)
+}
+
+func (q *Queries) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	if len(data) > 0 && data[0] == '{' {
+		var query Query
+
+		err := json.Unmarshal(data, &query)
+
+		if err != nil {
+			return err
+		}
+
+		*q = Queries{query}
+
+		return nil
+	}
+
+	var queries []Query
+
+	err := json.Unmarshal(data, &queries)
+
+	if err != nil {
+		return err
+	}
+
+	*q = queries
+
+	return nil
+}
+
+// MarshalJSON writes a single query as object so older servers can still read it
+func (q Queries) MarshalJSON() ([]byte, error) {
+	if len(q) == 1 {
+		return json.Marshal(q[0])
+	}
+
+	return json.Marshal([]Query(q))
 }
@@ -4,10 +4,38 @@
 
 package request
 
+import "encoding/json"
+
 type Request struct {
 	Query     *Query      `json:"query"`
-	Sort      *Sort       `json:"sort"`
+	SortKeys  SortKeys    `json:"sort"`
 	Implement []Implement `json:"implement"`
 	Skip      *int64      `json:"skip"`
 	Limit     *int64      `json:"limit"`
+	Fields    []string    `json:"fields"`
+	Exclude   []string    `json:"exclude"`
+	Aggregate *Aggregate  `json:"aggregate"`
+	Explain   bool        `json:"explain"`
+	Cursor    *string     `json:"cursor"`
+	// Deprecated: use SortKeys, Sort is only read by SortOrder and never set when unmarshalling
+	Sort *Sort `json:"-"`
+}
+
+// SortOrder returns the deprecated Sort followed by SortKeys
+func (r Request) SortOrder() SortKeys {
+	if r.Sort == nil {
+		return r.SortKeys
+	}
+
+	return append(SortKeys{*r.Sort}, r.SortKeys...)
+}
+
+// MarshalJSON writes the deprecated Sort together with SortKeys
+func (r Request) MarshalJSON() ([]byte, error) {
+	type plain Request
+
+	p := plain(r)
+	p.SortKeys = r.SortOrder()
+
+	return json.Marshal(p)
 }
@@ -4,14 +4,81 @@
 
 package request
 
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Sort is one sort key, objects with equal values for a key are ordered by the next key
 type Sort struct {
 	Field     string        `json:"field"`
 	Direction SortDirection `json:"direction"`
+	Nulls     NullsOrder    `json:"nulls,omitempty"`
 }
 
+// SortKeys is an ordered list of sort keys, a single key can also be sent as object instead of an array
+type SortKeys []Sort
+
 type SortDirection string
 
 const (
 	ASC  SortDirection = "asc"
 	DESC SortDirection = "desc"
 )
+
+type NullsOrder string
+
+const (
+	NULLS_FIRST NullsOrder = "first"
+	NULLS_LAST  NullsOrder = "last"
+)
+
+func (s *SortKeys) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	if len(data) > 0 && data[0] == '{' {
+		var key Sort
+
+		err := json.Unmarshal(data, &key)
+
+		if err != nil {
+			return err
+		}
+
+		*s = SortKeys{key}
+
+		return nil
+	}
+
+	var keys []Sort
+
+	err := json.Unmarshal(data, &keys)
+
+	if err != nil {
+		return err
+	}
+
+	*s = keys
+
+	return nil
+}
+
+// MarshalJSON writes a single key as object so older servers can still read it
+func (s SortKeys) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+
+	return json.Marshal([]Sort(s))
+}
+
+// Fields returns the fields of the sort keys in order
+func (s SortKeys) Fields() []string {
+	var fields []string
+
+	for _, key := range s {
+		fields = append(fields, key.Field)
+	}
+
+	return fields
+}
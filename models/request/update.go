@@ -0,0 +1,26 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package request
+
+import "encoding/json"
+
+// UpdateWhere changes the fields of all objects matching Query
+type UpdateWhere struct {
+	Query  *Query `json:"query"`
+	Update Update `json:"update"`
+}
+
+// Update maps field operators to their fields and values, $unset takes an array of fields
+type Update map[UpdateOperator]json.RawMessage
+
+type UpdateOperator string
+
+const (
+	SET    UpdateOperator = "$set"
+	UNSET  UpdateOperator = "$unset"
+	INC    UpdateOperator = "$inc"
+	MUL    UpdateOperator = "$mul"
+	CONCAT UpdateOperator = "$concat"
+)
@@ -12,4 +12,6 @@ type Where struct {
 	Value    json.RawMessage   `json:"value"`
 	All      []json.RawMessage `json:"all"`
 	Any      []json.RawMessage `json:"any"`
+	//only for startsWith, endsWith and contains
+	CaseInsensitive bool `json:"caseInsensitive,omitempty"`
 }
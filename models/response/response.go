@@ -7,6 +7,7 @@ package response
 import (
 	"encoding/json"
 	request2 "github.com/lucasl0st/InfiniteDB/models/request"
+	"time"
 )
 
 type GetDatabasesResponse struct {
@@ -29,10 +30,11 @@ type GetDatabaseResponse struct {
 }
 
 type GetDatabaseTableResponse struct {
-	Name      string                    `json:"name"`
-	TableName string                    `json:"tableName"`
-	Fields    map[string]request2.Field `json:"fields"`
-	Options   request2.TableOptions     `json:"options"`
+	Name        string                        `json:"name"`
+	TableName   string                        `json:"tableName"`
+	Fields      map[string]request2.Field     `json:"fields"`
+	Options     request2.TableOptions         `json:"options"`
+	IndexBuilds map[string]IndexBuildProgress `json:"indexBuilds,omitempty"`
 }
 
 type CreateTableInDatabaseResponse struct {
@@ -48,27 +50,194 @@ type DeleteTableInDatabaseResponse struct {
 }
 
 type GetFromDatabaseTableResponse struct {
-	Name      string                       `json:"name"`
-	TableName string                       `json:"tableName"`
-	Results   []map[string]json.RawMessage `json:"results"`
+	Name       string                       `json:"name"`
+	TableName  string                       `json:"tableName"`
+	Results    []map[string]json.RawMessage `json:"results"`
+	NextCursor *string                      `json:"nextCursor,omitempty"`
+	Explain    *ExplainStage                `json:"explain,omitempty"`
+}
+
+// GetFromDatabaseTableChunkResponse is one part of streamed results, the last chunk has no results and closes the stream
+type GetFromDatabaseTableChunkResponse struct {
+	Name       string                       `json:"name"`
+	TableName  string                       `json:"tableName"`
+	Results    []map[string]json.RawMessage `json:"results"`
+	Chunk      int64                        `json:"chunk"`
+	Done       bool                         `json:"done"`
+	Count      int64                        `json:"count,omitempty"`
+	NextCursor *string                      `json:"nextCursor,omitempty"`
+	Explain    *ExplainStage                `json:"explain,omitempty"`
+}
+
+// StreamLine is one line of a streamed HTTP get, every line has either an object, an error or done
+type StreamLine struct {
+	Object     map[string]json.RawMessage `json:"object,omitempty"`
+	Error      *string                    `json:"error,omitempty"`
+	Done       bool                       `json:"done,omitempty"`
+	Count      *int64                     `json:"count,omitempty"`
+	NextCursor *string                    `json:"nextCursor,omitempty"`
+}
+
+type ExplainStage struct {
+	Stage    string            `json:"stage"`
+	Field    string            `json:"field,omitempty"`
+	Operator request2.Operator `json:"operator,omitempty"`
+	Value    json.RawMessage   `json:"value,omitempty"`
+	Index    string            `json:"index,omitempty"`
+	Access   string            `json:"access,omitempty"`
+	Function string            `json:"function,omitempty"`
+	Estimate *int              `json:"estimate,omitempty"`
+	Before   int               `json:"before"`
+	After    int               `json:"after"`
+	Duration time.Duration     `json:"duration"`
+	Stages   []ExplainStage    `json:"stages,omitempty"`
+}
+
+type CountFromDatabaseTableResponse struct {
+	Name      string `json:"name"`
+	TableName string `json:"tableName"`
+	Count     int64  `json:"count"`
+}
+
+type ExistsInDatabaseTableResponse struct {
+	Name      string `json:"name"`
+	TableName string `json:"tableName"`
+	Exists    bool   `json:"exists"`
 }
 
 type InsertToDatabaseTableResponse struct {
+	Name          string                     `json:"name"`
+	TableName     string                     `json:"tableName"`
+	TransactionId *string                    `json:"transactionId,omitempty"`
+	Object        map[string]json.RawMessage `json:"object"`
+}
+
+type BulkInsertToDatabaseTableResponse struct {
+	Name      string             `json:"name"`
+	TableName string             `json:"tableName"`
+	Inserted  int64              `json:"inserted"`
+	Results   []BulkInsertResult `json:"results"`
+}
+
+type BulkInsertResult struct {
+	Object   map[string]json.RawMessage `json:"object"`
+	Inserted bool                       `json:"inserted"`
+	Error    *string                    `json:"error,omitempty"`
+}
+
+type RemoveFromDatabaseTableResponse struct {
+	Name          string  `json:"name"`
+	TableName     string  `json:"tableName"`
+	TransactionId *string `json:"transactionId,omitempty"`
+	Removed       int64   `json:"removed"`
+}
+
+type UpsertToDatabaseTableResponse struct {
 	Name      string                     `json:"name"`
 	TableName string                     `json:"tableName"`
+	Action    UpsertAction               `json:"action"`
 	Object    map[string]json.RawMessage `json:"object"`
 }
 
-type RemoveFromDatabaseTableResponse struct {
+type UpsertAction string
+
+const (
+	INSERTED UpsertAction = "inserted"
+	UPDATED  UpsertAction = "updated"
+)
+
+type UpdateWhereInDatabaseTableResponse struct {
 	Name      string `json:"name"`
 	TableName string `json:"tableName"`
-	Removed   int64  `json:"removed"`
+	Matched   int64  `json:"matched"`
+	Modified  int64  `json:"modified"`
 }
 
 type UpdateInDatabaseTableResponse struct {
-	Name      string                     `json:"name"`
-	TableName string                     `json:"tableName"`
-	Object    map[string]json.RawMessage `json:"object"`
+	Name          string                     `json:"name"`
+	TableName     string                     `json:"tableName"`
+	TransactionId *string                    `json:"transactionId,omitempty"`
+	Object        map[string]json.RawMessage `json:"object"`
+}
+
+type CompactDatabaseTableResponse struct {
+	Name         string `json:"name"`
+	TableName    string `json:"tableName"`
+	Message      string `json:"message"`
+	EventsBefore int64  `json:"eventsBefore"`
+	EventsAfter  int64  `json:"eventsAfter"`
+}
+
+type AlterTableResponse struct {
+	Name      string                    `json:"name"`
+	TableName string                    `json:"tableName"`
+	Message   string                    `json:"message"`
+	Fields    map[string]request2.Field `json:"fields"`
+}
+
+type CreateIndexResponse struct {
+	Name      string             `json:"name"`
+	TableName string             `json:"tableName"`
+	FieldName string             `json:"fieldName"`
+	Message   string             `json:"message"`
+	Progress  IndexBuildProgress `json:"progress"`
+}
+
+type DropIndexResponse struct {
+	Name      string `json:"name"`
+	TableName string `json:"tableName"`
+	FieldName string `json:"fieldName"`
+	Message   string `json:"message"`
+}
+
+type IndexBuildProgress struct {
+	Processed int64 `json:"processed"`
+	Total     int64 `json:"total"`
+}
+
+type BeginTransactionResponse struct {
+	Name          string `json:"name"`
+	TableName     string `json:"tableName"`
+	TransactionId string `json:"transactionId"`
+	Message       string `json:"message"`
+}
+
+type CommitTransactionResponse struct {
+	Name          string `json:"name"`
+	TableName     string `json:"tableName"`
+	TransactionId string `json:"transactionId"`
+	Message       string `json:"message"`
+	Operations    int64  `json:"operations"`
+}
+
+type RollbackTransactionResponse struct {
+	Name          string `json:"name"`
+	TableName     string `json:"tableName"`
+	TransactionId string `json:"transactionId"`
+	Message       string `json:"message"`
+}
+
+type OpenCursorResponse struct {
+	Name      string    `json:"name"`
+	TableName string    `json:"tableName"`
+	CursorId  string    `json:"cursorId"`
+	Expires   time.Time `json:"expires"`
+	Message   string    `json:"message"`
+}
+
+type FetchCursorResponse struct {
+	Name      string                       `json:"name"`
+	TableName string                       `json:"tableName"`
+	CursorId  string                       `json:"cursorId"`
+	Results   []map[string]json.RawMessage `json:"results"`
+	Done      bool                         `json:"done"`
+}
+
+type CloseCursorResponse struct {
+	Name      string `json:"name"`
+	TableName string `json:"tableName"`
+	CursorId  string `json:"cursorId"`
+	Message   string `json:"message"`
 }
 
 type SubscribeToMetricUpdatesResponse struct {
@@ -19,6 +19,7 @@ type Config struct {
 	TLSCert            string `env:"TLS_CERT"`
 	TLSKey             string `env:"TLS_KEY"`
 	WebsocketReadLimit int64  `env:"WEBSOCKET_READ_LIMIT" envDefault:"10000000"`
+	ScanLimit          uint   `env:"SCAN_LIMIT" envDefault:"0"`
 }
 
 func LoadConfig() (*Config, error) {
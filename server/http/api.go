@@ -49,7 +49,19 @@ func (a *Api) registerHandlers(r *gin.Engine) {
 	r.POST(apiPrefix+"/database/:name/table", a.createTableInDatabaseHandler)
 	r.DELETE(apiPrefix+"/database/:name/table/:tableName", a.deleteTableInDatabaseHandler)
 	r.POST(apiPrefix+"/database/:name/table/:tableName/get", a.getFromDatabaseTableHandler)
+	r.POST(apiPrefix+"/database/:name/table/:tableName/count", a.countFromDatabaseTableHandler)
+	r.POST(apiPrefix+"/database/:name/table/:tableName/exists", a.existsInDatabaseTableHandler)
 	r.POST(apiPrefix+"/database/:name/table/:tableName/insert", a.insertToDatabaseTableHandler)
+	r.POST(apiPrefix+"/database/:name/table/:tableName/bulkInsert", a.bulkInsertToDatabaseTableHandler)
 	r.POST(apiPrefix+"/database/:name/table/:tableName/remove", a.removeFromDatabaseTableHandler)
 	r.POST(apiPrefix+"/database/:name/table/:tableName/update", a.updateInDatabaseTableHandler)
+	r.POST(apiPrefix+"/database/:name/table/:tableName/upsert", a.upsertToDatabaseTableHandler)
+	r.POST(apiPrefix+"/database/:name/table/:tableName/updateWhere", a.updateWhereHandler)
+	r.POST(apiPrefix+"/database/:name/table/:tableName/compact", a.compactDatabaseTableHandler)
+	r.POST(apiPrefix+"/database/:name/table/:tableName/alter", a.alterTableHandler)
+	r.POST(apiPrefix+"/database/:name/table/:tableName/index/:fieldName", a.createIndexHandler)
+	r.DELETE(apiPrefix+"/database/:name/table/:tableName/index/:fieldName", a.dropIndexHandler)
+	r.POST(apiPrefix+"/database/:name/table/:tableName/transaction", a.beginTransactionHandler)
+	r.POST(apiPrefix+"/transaction/:transactionId/commit", a.commitTransactionHandler)
+	r.POST(apiPrefix+"/transaction/:transactionId/rollback", a.rollbackTransactionHandler)
 }
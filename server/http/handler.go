@@ -8,14 +8,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/lucasl0st/InfiniteDB/idblib/table"
 	"github.com/lucasl0st/InfiniteDB/models/request"
+	"github.com/lucasl0st/InfiniteDB/models/response"
 	"github.com/lucasl0st/InfiniteDB/server/internal_database"
 	"github.com/lucasl0st/InfiniteDB/server/parse"
 	"github.com/lucasl0st/InfiniteDB/server/util"
+	infinitedbutil "github.com/lucasl0st/InfiniteDB/util"
 	"io"
 	"net/http"
+	"strconv"
 )
 
+const ndjsonContentType = "application/x-ndjson"
+const nextCursorTrailer = "Next-Cursor"
+
 func (a *Api) authenticationHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !a.authentication {
@@ -288,6 +295,11 @@ func (a *Api) getFromDatabaseTableHandler(c *gin.Context) {
 			return
 		}
 
+		if c.Query("stream") == "true" || c.GetHeader("Accept") == ndjsonContentType {
+			a.streamFromDatabaseTable(c, name, tableName, *parsedRequest)
+			return
+		}
+
 		results, err := a.idb.GetFromDatabaseTable(name, tableName, *parsedRequest)
 
 		if err == nil {
@@ -298,6 +310,155 @@ func (a *Api) getFromDatabaseTableHandler(c *gin.Context) {
 	}
 }
 
+// streamFromDatabaseTable writes one line per object and flushes after every chunk, the last line is done with the count and
+// the cursor of the next page or an error after the first chunk. The cursor is also sent in the Next-Cursor trailer
+func (a *Api) streamFromDatabaseTable(c *gin.Context, name string, tableName string, parsedRequest table.Request) {
+	chunkSize := util.DefaultChunkSize
+
+	if c.Query("chunkSize") != "" {
+		size, err := strconv.Atoi(c.Query("chunkSize"))
+
+		if err != nil || size < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "chunkSize is not a number"})
+			return
+		}
+
+		chunkSize = size
+	}
+
+	started := false
+
+	start := func() {
+		c.Header("Content-Type", ndjsonContentType)
+		c.Header("Trailer", nextCursorTrailer)
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+		started = true
+	}
+
+	end, err := a.idb.StreamFromDatabaseTable(name, tableName, parsedRequest, chunkSize, func(chunk response.GetFromDatabaseTableChunkResponse) error {
+		if !started {
+			start()
+		}
+
+		encoder := json.NewEncoder(c.Writer)
+
+		for _, object := range chunk.Results {
+			err := encoder.Encode(response.StreamLine{Object: object})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		c.Writer.Flush()
+
+		return nil
+	})
+
+	if err != nil {
+		if !started {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		_ = json.NewEncoder(c.Writer).Encode(response.StreamLine{Error: infinitedbutil.Ptr(fmt.Sprint(err))})
+		return
+	}
+
+	if !started {
+		start()
+	}
+
+	_ = json.NewEncoder(c.Writer).Encode(response.StreamLine{
+		Done:       true,
+		Count:      infinitedbutil.Ptr(end.Count),
+		NextCursor: end.NextCursor,
+	})
+
+	if end.NextCursor != nil {
+		c.Writer.Header().Set(nextCursorTrailer, *end.NextCursor)
+	}
+}
+
+func (a *Api) countFromDatabaseTableHandler(c *gin.Context) {
+	r := a.getRequest(c)
+
+	if r != nil {
+		name := c.Param("name")
+
+		err := util.ValidateName(name)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		tableName := c.Param("tableName")
+
+		err = util.ValidateName(tableName)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		parsedRequest, err := parse.Request(*r)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		results, err := a.idb.CountFromDatabaseTable(name, tableName, *parsedRequest)
+
+		if err == nil {
+			c.JSON(http.StatusOK, results)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+		}
+	}
+}
+
+func (a *Api) existsInDatabaseTableHandler(c *gin.Context) {
+	r := a.getRequest(c)
+
+	if r != nil {
+		name := c.Param("name")
+
+		err := util.ValidateName(name)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		tableName := c.Param("tableName")
+
+		err = util.ValidateName(tableName)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		parsedRequest, err := parse.Request(*r)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		results, err := a.idb.ExistsInDatabaseTable(name, tableName, *parsedRequest)
+
+		if err == nil {
+			c.JSON(http.StatusOK, results)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+		}
+	}
+}
+
 func (a *Api) insertToDatabaseTableHandler(c *gin.Context) {
 	body := a.getJsonRawBody(c)
 
@@ -320,6 +481,27 @@ func (a *Api) insertToDatabaseTableHandler(c *gin.Context) {
 			return
 		}
 
+		transactionId := c.Query("transactionId")
+
+		if transactionId != "" {
+			err = util.ValidateName(transactionId)
+
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+				return
+			}
+
+			results, err := a.idb.InsertToDatabaseTableInTransaction(transactionId, &name, &tableName, *body)
+
+			if err == nil {
+				c.JSON(http.StatusOK, results)
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+			}
+
+			return
+		}
+
 		results, err := a.idb.InsertToDatabaseTable(name, tableName, *body)
 
 		if err == nil {
@@ -330,6 +512,38 @@ func (a *Api) insertToDatabaseTableHandler(c *gin.Context) {
 	}
 }
 
+func (a *Api) bulkInsertToDatabaseTableHandler(c *gin.Context) {
+	body := a.getJsonRawArrayBody(c)
+
+	if body != nil {
+		name := c.Param("name")
+
+		err := util.ValidateName(name)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		tableName := c.Param("tableName")
+
+		err = util.ValidateName(tableName)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		results, err := a.idb.BulkInsertToDatabaseTable(name, tableName, *body)
+
+		if err == nil {
+			c.JSON(http.StatusOK, results)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+		}
+	}
+}
+
 func (a *Api) removeFromDatabaseTableHandler(c *gin.Context) {
 	r := a.getRequest(c)
 
@@ -359,6 +573,27 @@ func (a *Api) removeFromDatabaseTableHandler(c *gin.Context) {
 			return
 		}
 
+		transactionId := c.Query("transactionId")
+
+		if transactionId != "" {
+			err = util.ValidateName(transactionId)
+
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+				return
+			}
+
+			results, err := a.idb.RemoveFromDatabaseTableInTransaction(transactionId, &name, &tableName, *parsedRequest)
+
+			if err == nil {
+				c.JSON(http.StatusOK, results)
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+			}
+
+			return
+		}
+
 		results, err := a.idb.RemoveFromDatabaseTable(name, tableName, *parsedRequest)
 
 		if err == nil {
@@ -391,6 +626,27 @@ func (a *Api) updateInDatabaseTableHandler(c *gin.Context) {
 			return
 		}
 
+		transactionId := c.Query("transactionId")
+
+		if transactionId != "" {
+			err = util.ValidateName(transactionId)
+
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+				return
+			}
+
+			results, err := a.idb.UpdateInDatabaseTableInTransaction(transactionId, &name, &tableName, *body)
+
+			if err == nil {
+				c.JSON(http.StatusOK, results)
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+			}
+
+			return
+		}
+
 		results, err := a.idb.UpdateInDatabaseTable(name, tableName, *body)
 
 		if err == nil {
@@ -401,6 +657,286 @@ func (a *Api) updateInDatabaseTableHandler(c *gin.Context) {
 	}
 }
 
+func (a *Api) upsertToDatabaseTableHandler(c *gin.Context) {
+	body := a.getJsonRawBody(c)
+
+	if body != nil {
+		name := c.Param("name")
+
+		err := util.ValidateName(name)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		tableName := c.Param("tableName")
+
+		err = util.ValidateName(tableName)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		results, err := a.idb.UpsertToDatabaseTable(name, tableName, *body)
+
+		if err == nil {
+			c.JSON(http.StatusOK, results)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+		}
+	}
+}
+
+func (a *Api) updateWhereHandler(c *gin.Context) {
+	body := a.getBody(c)
+
+	if body != nil {
+		name := c.Param("name")
+
+		err := util.ValidateName(name)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		tableName := c.Param("tableName")
+
+		err = util.ValidateName(tableName)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		var u request.UpdateWhere
+		err = util.ToStruct(*body, &u)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		q, updates, err := parse.UpdateWhere(u)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		results, err := a.idb.UpdateWhereInDatabaseTable(name, tableName, *q, updates)
+
+		if err == nil {
+			c.JSON(http.StatusOK, results)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+		}
+	}
+}
+
+func (a *Api) compactDatabaseTableHandler(c *gin.Context) {
+	name := c.Param("name")
+
+	err := util.ValidateName(name)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+		return
+	}
+
+	tableName := c.Param("tableName")
+
+	err = util.ValidateName(tableName)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+		return
+	}
+
+	results, err := a.idb.CompactDatabaseTable(name, tableName)
+
+	if err == nil {
+		c.JSON(http.StatusOK, results)
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+	}
+}
+
+func (a *Api) alterTableHandler(c *gin.Context) {
+	body := a.getBody(c)
+
+	if body != nil {
+		name := c.Param("name")
+
+		err := util.ValidateName(name)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		tableName := c.Param("tableName")
+
+		err = util.ValidateName(tableName)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		var changes []request.AlterTableChange
+		err = util.ToStruct((*body)["changes"], &changes)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		parsedChanges, err := parse.AlterTableChanges(changes)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+			return
+		}
+
+		results, err := a.idb.AlterTable(name, tableName, parsedChanges)
+
+		if err == nil {
+			c.JSON(http.StatusOK, results)
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+		}
+	}
+}
+
+func (a *Api) createIndexHandler(c *gin.Context) {
+	name := c.Param("name")
+
+	err := util.ValidateName(name)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+		return
+	}
+
+	tableName := c.Param("tableName")
+
+	err = util.ValidateName(tableName)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+		return
+	}
+
+	fieldName := c.Param("fieldName")
+
+	results, err := a.idb.CreateIndex(name, tableName, fieldName)
+
+	if err == nil {
+		c.JSON(http.StatusOK, results)
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+	}
+}
+
+func (a *Api) dropIndexHandler(c *gin.Context) {
+	name := c.Param("name")
+
+	err := util.ValidateName(name)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+		return
+	}
+
+	tableName := c.Param("tableName")
+
+	err = util.ValidateName(tableName)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+		return
+	}
+
+	fieldName := c.Param("fieldName")
+
+	results, err := a.idb.DropIndex(name, tableName, fieldName)
+
+	if err == nil {
+		c.JSON(http.StatusOK, results)
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+	}
+}
+
+func (a *Api) beginTransactionHandler(c *gin.Context) {
+	name := c.Param("name")
+
+	err := util.ValidateName(name)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+		return
+	}
+
+	tableName := c.Param("tableName")
+
+	err = util.ValidateName(tableName)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+		return
+	}
+
+	results, err := a.idb.BeginTransaction(name, tableName)
+
+	if err == nil {
+		c.JSON(http.StatusOK, results)
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+	}
+}
+
+func (a *Api) commitTransactionHandler(c *gin.Context) {
+	transactionId := c.Param("transactionId")
+
+	err := util.ValidateName(transactionId)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+		return
+	}
+
+	results, err := a.idb.CommitTransaction(transactionId)
+
+	if err == nil {
+		c.JSON(http.StatusOK, results)
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+	}
+}
+
+func (a *Api) rollbackTransactionHandler(c *gin.Context) {
+	transactionId := c.Param("transactionId")
+
+	err := util.ValidateName(transactionId)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprint(err)})
+		return
+	}
+
+	results, err := a.idb.RollbackTransaction(transactionId)
+
+	if err == nil {
+		c.JSON(http.StatusOK, results)
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprint(err)})
+	}
+}
+
 func (a *Api) getBody(c *gin.Context) *map[string]interface{} {
 	bytes, err := io.ReadAll(c.Request.Body)
 
@@ -458,3 +994,23 @@ func (a *Api) getRequest(c *gin.Context) *request.Request {
 
 	return &r
 }
+
+func (a *Api) getJsonRawArrayBody(c *gin.Context) *[]map[string]json.RawMessage {
+	bytes, err := io.ReadAll(c.Request.Body)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to read body"})
+		return nil
+	}
+
+	var m []map[string]json.RawMessage
+
+	err = json.Unmarshal(bytes, &m)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to parse JSON", "error": err.Error()})
+		return nil
+	}
+
+	return &m
+}
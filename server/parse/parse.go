@@ -10,13 +10,71 @@ import (
 	"github.com/lucasl0st/InfiniteDB/idblib/field"
 	"github.com/lucasl0st/InfiniteDB/idblib/functions"
 	"github.com/lucasl0st/InfiniteDB/idblib/table"
+	idbutil "github.com/lucasl0st/InfiniteDB/idblib/util"
 	e "github.com/lucasl0st/InfiniteDB/models/errors"
 	"github.com/lucasl0st/InfiniteDB/models/request"
+	"github.com/lucasl0st/InfiniteDB/util"
+	"sort"
 )
 
 func Request(r request.Request) (*table.Request, error) {
 	var err error
 
+	if len(r.Fields) > 0 && len(r.Exclude) > 0 {
+		return nil, e.OnlyFieldsOrExclude()
+	}
+
+	var a *request.Aggregate
+
+	if r.Aggregate != nil {
+		a, err = Aggregate(*r.Aggregate)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := Sort(r.SortOrder())
+
+	if err != nil {
+		return nil, err
+	}
+
+	var c *table.Cursor
+
+	if r.Cursor != nil {
+		if r.Aggregate != nil {
+			return nil, e.CannotUseCursorWithAggregate()
+		}
+
+		if r.Skip != nil {
+			return nil, e.CannotSkipWithCursor()
+		}
+
+		cursor := table.NewCursor(s)
+
+		//an empty cursor starts at the first object
+		if len(*r.Cursor) > 0 {
+			cursor, err = table.DecodeCursor(*r.Cursor)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !cursor.Matches(s) {
+				return nil, e.CursorDoesNotMatchSort()
+			}
+		}
+
+		c = &cursor
+	}
+
+	i, err := Implements(r.Implement)
+
+	if err != nil {
+		return nil, err
+	}
+
 	var q *table.Query
 
 	if r.Query != nil {
@@ -29,10 +87,189 @@ func Request(r request.Request) (*table.Request, error) {
 
 	return &table.Request{
 		Query:     q,
-		Sort:      r.Sort,
-		Implement: r.Implement,
+		Sort:      s,
+		Implement: i,
 		Skip:      r.Skip,
 		Limit:     r.Limit,
+		Fields:    r.Fields,
+		Exclude:   r.Exclude,
+		Aggregate: a,
+		Explain:   r.Explain,
+		Cursor:    c,
+	}, nil
+}
+
+func Implements(implements []request.Implement) ([]table.Implement, error) {
+	var results []table.Implement
+
+	for _, implement := range implements {
+		on, err := implementOn(implement)
+
+		if err != nil {
+			return nil, err
+		}
+
+		i := table.Implement{
+			Table:  implement.From.Table,
+			On:     on,
+			Type:   request.LEFT,
+			As:     implement.From.Table,
+			Fields: implement.Fields,
+			Limit:  implement.Limit,
+		}
+
+		switch implement.Type {
+		case "":
+		case request.LEFT, request.INNER, request.ANTI:
+			i.Type = implement.Type
+		default:
+			return nil, e.NotAValidImplementType(string(implement.Type))
+		}
+
+		if implement.As != nil {
+			i.As = *implement.As
+		}
+
+		if implement.ForceArray != nil {
+			i.ForceArray = *implement.ForceArray
+		}
+
+		if implement.Query != nil {
+			i.Query, err = Query(*implement.Query)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		i.Sort, err = Sort(implement.Sort)
+
+		if err != nil {
+			return nil, err
+		}
+
+		i.Implement, err = Implements(implement.Implement)
+
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, i)
+	}
+
+	return results, nil
+}
+
+// implementOn returns the pairs of fields to match, either from on or from from.field and field
+func implementOn(implement request.Implement) ([]request.ImplementOn, error) {
+	if len(implement.From.Table) == 0 {
+		return nil, e.ImplementNeedsTableAndFields()
+	}
+
+	if len(implement.On) == 0 {
+		if len(implement.From.Field) == 0 || len(implement.Field) == 0 {
+			return nil, e.ImplementNeedsTableAndFields()
+		}
+
+		return []request.ImplementOn{{Field: implement.Field, FromField: implement.From.Field}}, nil
+	}
+
+	if len(implement.From.Field) > 0 || len(implement.Field) > 0 {
+		return nil, e.CannotHaveOnAndFieldsInOneImplement()
+	}
+
+	for _, on := range implement.On {
+		if len(on.Field) == 0 || len(on.FromField) == 0 {
+			return nil, e.ImplementNeedsTableAndFields()
+		}
+	}
+
+	return implement.On, nil
+}
+
+// Sort checks the sort keys, without a direction a key is sorted ascending and null values come last
+func Sort(s request.SortKeys) (request.SortKeys, error) {
+	fields := map[string]bool{}
+
+	var keys request.SortKeys
+
+	for _, key := range s {
+		if len(key.Field) == 0 {
+			return nil, e.SortKeyNeedsField()
+		}
+
+		if fields[key.Field] {
+			return nil, e.SortFieldIsUsedMoreThanOnce(key.Field)
+		}
+
+		fields[key.Field] = true
+
+		switch key.Direction {
+		case "":
+			key.Direction = request.ASC
+		case request.ASC, request.DESC:
+		default:
+			return nil, e.NotAValidSortDirection(string(key.Direction))
+		}
+
+		switch key.Nulls {
+		case "":
+			key.Nulls = request.NULLS_LAST
+		case request.NULLS_FIRST, request.NULLS_LAST:
+		default:
+			return nil, e.NotAValidNullsOrder(string(key.Nulls))
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func Aggregate(a request.Aggregate) (*request.Aggregate, error) {
+	names := map[string]bool{}
+
+	for _, fieldName := range a.GroupBy {
+		if names[fieldName] {
+			return nil, e.AggregateNameIsUsedMoreThanOnce(fieldName)
+		}
+
+		names[fieldName] = true
+	}
+
+	var accumulators []request.Accumulator
+
+	for _, accumulator := range a.Accumulators {
+		switch accumulator.Accumulator {
+		case request.COUNT:
+		case request.SUM, request.AVG, request.MIN, request.MAX, request.COUNT_DISTINCT:
+			if len(accumulator.Field) == 0 {
+				return nil, e.AccumulatorNeedsField(string(accumulator.Accumulator))
+			}
+		default:
+			return nil, e.NotAValidAccumulator(string(accumulator.Accumulator))
+		}
+
+		if len(accumulator.As) == 0 {
+			accumulator.As = string(accumulator.Accumulator)
+
+			if len(accumulator.Field) > 0 {
+				accumulator.As += "_" + accumulator.Field
+			}
+		}
+
+		if names[accumulator.As] {
+			return nil, e.AggregateNameIsUsedMoreThanOnce(accumulator.As)
+		}
+
+		names[accumulator.As] = true
+
+		accumulators = append(accumulators, accumulator)
+	}
+
+	return &request.Aggregate{
+		GroupBy:      a.GroupBy,
+		Accumulators: accumulators,
 	}, nil
 }
 
@@ -43,51 +280,234 @@ func Query(q request.Query) (*table.Query, error) {
 		return nil, err
 	}
 
-	var and *table.Query = nil
+	and, err := Queries(q.Ands())
 
-	if q.And != nil {
-		and, err = Query(*q.And)
+	if err != nil {
+		return nil, err
+	}
+
+	or, err := Queries(q.Ors())
+
+	if err != nil {
+		return nil, err
+	}
+
+	not, err := Queries(q.Not)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := table.Query{
+		Where:     q.Where,
+		Functions: f,
+		And:       and,
+		Or:        or,
+		Not:       not,
+	}
+
+	if q.Where != nil {
+		err = Where(*q.Where)
 
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	var or *table.Query = nil
+	return &query, nil
+}
 
-	if q.Or != nil {
-		or, err = Query(*q.Or)
+func Queries(queries request.Queries) ([]table.Query, error) {
+	var results []table.Query
+
+	for _, q := range queries {
+		query, err := Query(q)
 
 		if err != nil {
 			return nil, err
 		}
+
+		results = append(results, *query)
 	}
 
-	if and != nil && or != nil {
-		return nil, e.CannotHaveAndANDOrInOneQuery()
+	return results, nil
+}
+
+// Where checks that the values of w fit its operator, the types of the values are checked against the field by the table
+func Where(w request.Where) error {
+	if w.Value != nil && (w.All != nil || w.Any != nil) ||
+		w.All != nil && (w.Value != nil || w.Any != nil) ||
+		w.Any != nil && (w.Value != nil || w.All != nil) {
+		return e.OnlyValueAllOrAny()
 	}
 
-	query := table.Query{
-		Where:     q.Where,
-		Functions: f,
-		And:       and,
-		Or:        or,
+	values := []json.RawMessage{w.Value}
+
+	if w.All != nil {
+		values = w.All
+	} else if w.Any != nil {
+		values = w.Any
 	}
 
-	if query.Where.Value != nil && (query.Where.All != nil || query.Where.Any != nil) ||
-		query.Where.All != nil && (query.Where.Value != nil || query.Where.Any != nil) ||
-		query.Where.Any != nil && (query.Where.Value != nil || query.Where.All != nil) {
-		return nil, e.OnlyValueAllOrAny()
+	if w.CaseInsensitive && !w.Operator.IsTextOperator() {
+		return e.OperatorCannotBeCaseInsensitive(w.Operator)
 	}
 
-	return &query, nil
+	switch w.Operator {
+	case request.EQUALS, request.NOT, request.LARGER, request.SMALLER, request.LARGER_OR_EQUAL, request.SMALLER_OR_EQUAL, request.BETWEEN:
+	case request.IS_NULL, request.IS_NOT_NULL:
+		for _, value := range values {
+			if value != nil && string(value) != "null" {
+				return e.OperatorDoesNotTakeValue(w.Operator)
+			}
+		}
+	case request.IN, request.NOT_IN:
+		for _, value := range values {
+			if !isArray(value) {
+				return e.ValueForOperatorMustBeArray(w.Operator)
+			}
+		}
+	case request.MATCH, request.STARTS_WITH, request.ENDS_WITH, request.CONTAINS:
+		for _, value := range values {
+			if !isString(value) {
+				return e.ValueForOperatorMustBeString(w.Operator)
+			}
+		}
+	default:
+		return e.NotAValidOperator()
+	}
+
+	return nil
+}
+
+func isArray(value json.RawMessage) bool {
+	var values []json.RawMessage
+
+	return json.Unmarshal(value, &values) == nil && values != nil
+}
+
+func isString(value json.RawMessage) bool {
+	s, err := util.JsonRawToString(value)
+
+	return err == nil && s != nil
+}
+
+// UpdateWhere checks the selector and the field operators of an update by query
+func UpdateWhere(u request.UpdateWhere) (*table.Query, []table.FieldUpdate, error) {
+	if u.Query == nil {
+		return nil, nil, e.UpdateNeedsQuery()
+	}
+
+	q, err := Query(*u.Query)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updates, err := Update(u.Update)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return q, updates, nil
+}
+
+// Update returns the changes of the update document in a fixed order, every field can only be changed by one operator
+func Update(u request.Update) ([]table.FieldUpdate, error) {
+	for operator := range u {
+		switch operator {
+		case request.SET, request.UNSET, request.INC, request.MUL, request.CONCAT:
+		default:
+			return nil, e.NotAValidUpdateOperator(string(operator))
+		}
+	}
+
+	fields := map[string]bool{}
+
+	var updates []table.FieldUpdate
+
+	for _, operator := range []request.UpdateOperator{request.SET, request.UNSET, request.INC, request.MUL, request.CONCAT} {
+		raw, ok := u[operator]
+
+		if !ok {
+			continue
+		}
+
+		values := map[string]json.RawMessage{}
+
+		if operator == request.UNSET {
+			var fieldNames []string
+
+			err := json.Unmarshal(raw, &fieldNames)
+
+			if err != nil {
+				return nil, e.ValueForUpdateOperatorMustBeArray(string(operator))
+			}
+
+			for _, fieldName := range fieldNames {
+				values[fieldName] = json.RawMessage("null")
+			}
+		} else {
+			err := json.Unmarshal(raw, &values)
+
+			if err != nil {
+				return nil, e.ValueForUpdateOperatorMustBeObject(string(operator))
+			}
+		}
+
+		var fieldNames []string
+
+		for fieldName := range values {
+			fieldNames = append(fieldNames, fieldName)
+		}
+
+		sort.Strings(fieldNames)
+
+		for _, fieldName := range fieldNames {
+			value := values[fieldName]
+
+			switch operator {
+			case request.INC, request.MUL:
+				n, err := util.JsonRawToStringNumber(value)
+
+				if err != nil || n == nil {
+					return nil, e.IsNotANumber(fieldName)
+				}
+			case request.CONCAT:
+				if !isString(value) {
+					return nil, e.IsNotAString(fieldName)
+				}
+			}
+
+			if fields[fieldName] {
+				return nil, e.FieldIsUpdatedMoreThanOnce(fieldName)
+			}
+
+			fields[fieldName] = true
+
+			updates = append(updates, table.FieldUpdate{
+				Operator: operator,
+				Field:    fieldName,
+				Value:    value,
+			})
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil, e.UpdateNeedsFields()
+	}
+
+	return updates, nil
 }
 
 func Functions(f []request.Function) ([]table.FunctionWithParameters, error) {
 	var results []table.FunctionWithParameters
 
 	for _, function := range f {
-		ff := table.FunctionWithParameters{}
+		ff := table.FunctionWithParameters{
+			Name: function.Function,
+		}
 
 		if function.Parameters != nil {
 			ff.Parameters = *function.Parameters
@@ -145,14 +565,61 @@ func Fields(fields map[string]request.Field) (map[string]field.Field, error) {
 			null = *f.Null
 		}
 
-		resultMap[fieldName] = field.Field{
+		parsed := field.Field{
 			Name:    fieldName,
 			Indexed: indexed,
 			Unique:  unique,
 			Type:    *t,
 			Null:    null,
 		}
+
+		if f.Default != nil {
+			v, err := idbutil.JsonRawToDBType(f.Default, parsed)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !v.IsNull() {
+				parsed.Default = util.Ptr(v.ToString())
+			}
+		}
+
+		resultMap[fieldName] = parsed
 	}
 
 	return resultMap, nil
 }
+
+func AlterTableChanges(changes []request.AlterTableChange) ([]table.AlterTableChange, error) {
+	var results []table.AlterTableChange
+
+	for _, c := range changes {
+		switch c.Action {
+		case request.ADD_FIELD, request.DROP_FIELD, request.ALTER_FIELD:
+		default:
+			return nil, e.NotAValidAlterTableAction(string(c.Action))
+		}
+
+		change := table.AlterTableChange{
+			Action:    c.Action,
+			FieldName: c.FieldName,
+			Indexed:   c.Indexed,
+			Unique:    c.Unique,
+			Null:      c.Null,
+			Default:   c.Default,
+		}
+
+		if c.Type != nil {
+			change.Type = dbtype.ParseDatabaseType(*c.Type)
+
+			if change.Type == nil {
+				return nil, e.TypeNotSupported(*c.Type)
+			}
+		}
+
+		results = append(results, change)
+	}
+
+	return results, nil
+}
@@ -57,7 +57,7 @@ func New(
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	idb, err := idblib.New(config.DatabasePath, idbLogger, &metricsReceiver, config.CacheSize, func() {
+	idb, err := idblib.New(config.DatabasePath, idbLogger, &metricsReceiver, config.CacheSize, config.ScanLimit, func() {
 		//make sure s.idb is set
 		wg.Wait()
 
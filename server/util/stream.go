@@ -0,0 +1,8 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package util
+
+// DefaultChunkSize is the number of objects per chunk of streamed results if the request does not set one
+const DefaultChunkSize = 1000
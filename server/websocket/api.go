@@ -18,6 +18,7 @@ import (
 	"github.com/lucasl0st/InfiniteDB/server/util"
 	infinitedbutil "github.com/lucasl0st/InfiniteDB/util"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -34,6 +35,10 @@ type Api struct {
 
 	subscribedToMetricUpdates []*websocket.Conn
 
+	//transactions begun on a connection, they are rolled back when it disconnects
+	transactions     map[*websocket.Conn]map[string]bool
+	transactionsLock sync.Mutex
+
 	readLimit int64
 
 	shutdown func()
@@ -41,11 +46,12 @@ type Api struct {
 
 func New(idb *idblib.IDB, logging bool, logger idbutil.Logger, readLimit int64, shutdown func()) *Api {
 	return &Api{
-		idb:       idb,
-		logging:   logging,
-		l:         logger,
-		readLimit: readLimit,
-		shutdown:  shutdown,
+		idb:          idb,
+		logging:      logging,
+		l:            logger,
+		readLimit:    readLimit,
+		shutdown:     shutdown,
+		transactions: map[*websocket.Conn]map[string]bool{},
 	}
 }
 
@@ -80,6 +86,8 @@ func (a *Api) handler(ctx *gin.Context, rw http.ResponseWriter, r *http.Request)
 }
 
 func (a *Api) read(ctx *gin.Context, conn *websocket.Conn) {
+	defer a.rollbackTransactions(conn)
+
 	for {
 		_, bytes, err := conn.ReadMessage()
 
@@ -140,6 +148,37 @@ func (a *Api) read(ctx *gin.Context, conn *websocket.Conn) {
 	}
 }
 
+func (a *Api) addTransaction(conn *websocket.Conn, transactionId string) {
+	a.transactionsLock.Lock()
+	defer a.transactionsLock.Unlock()
+
+	if a.transactions[conn] == nil {
+		a.transactions[conn] = map[string]bool{}
+	}
+
+	a.transactions[conn][transactionId] = true
+}
+
+func (a *Api) removeTransaction(conn *websocket.Conn, transactionId string) {
+	a.transactionsLock.Lock()
+	defer a.transactionsLock.Unlock()
+
+	delete(a.transactions[conn], transactionId)
+}
+
+// rollbackTransactions rolls back the transactions of a connection that were not committed or rolled back before it disconnected
+func (a *Api) rollbackTransactions(conn *websocket.Conn) {
+	a.transactionsLock.Lock()
+	transactions := a.transactions[conn]
+	delete(a.transactions, conn)
+	a.transactionsLock.Unlock()
+
+	for transactionId := range transactions {
+		//the transaction may have been ended over http or expired already
+		_, _ = a.idb.RollbackTransaction(transactionId)
+	}
+}
+
 func (a *Api) send(conn *websocket.Conn, msg any) bool {
 	err := conn.WriteJSON(msg)
 
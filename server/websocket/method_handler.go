@@ -7,14 +7,19 @@ package websocket
 import (
 	"encoding/json"
 	"github.com/gorilla/websocket"
+	"github.com/lucasl0st/InfiniteDB/idblib/table"
 	e "github.com/lucasl0st/InfiniteDB/models/errors"
 	"github.com/lucasl0st/InfiniteDB/models/method"
 	models "github.com/lucasl0st/InfiniteDB/models/request"
 	"github.com/lucasl0st/InfiniteDB/models/response"
 	"github.com/lucasl0st/InfiniteDB/server/parse"
 	"github.com/lucasl0st/InfiniteDB/server/util"
+	"time"
 )
 
+// how long a cursor is kept without being fetched if the request does not set a ttl
+const defaultCursorTtl = time.Minute
+
 var MethodHandlers []MethodHandler
 
 type Handler func(a *Api, conn *websocket.Conn, request map[string]interface{}, rawRequest map[string]json.RawMessage) (any, error)
@@ -34,9 +39,24 @@ func init() {
 	registerHandler(method.CreateTableInDatabaseMethod, createTableInDatabaseHandler)
 	registerHandler(method.DeleteTableInDatabaseMethod, deleteTableInDatabaseHandler)
 	registerHandler(method.GetFromDatabaseTableMethod, getFromDatabaseTableHandler)
+	registerHandler(method.CountFromDatabaseTableMethod, countFromDatabaseTableHandler)
+	registerHandler(method.ExistsInDatabaseTableMethod, existsInDatabaseTableHandler)
+	registerHandler(method.OpenCursorMethod, openCursorHandler)
+	registerHandler(method.FetchCursorMethod, fetchCursorHandler)
+	registerHandler(method.CloseCursorMethod, closeCursorHandler)
 	registerHandler(method.InsertToDatabaseTableMethod, insertToDatabaseTableHandler)
+	registerHandler(method.BulkInsertToDatabaseTableMethod, bulkInsertToDatabaseTableHandler)
 	registerHandler(method.RemoveFromDatabaseTableMethod, removeFromDatabaseTableHandler)
 	registerHandler(method.UpdateInDatabaseTableMethod, updateInDatabaseTableHandler)
+	registerHandler(method.UpsertToDatabaseTableMethod, upsertToDatabaseTableHandler)
+	registerHandler(method.UpdateWhereMethod, updateWhereHandler)
+	registerHandler(method.CompactDatabaseTableMethod, compactDatabaseTableHandler)
+	registerHandler(method.AlterTableMethod, alterTableHandler)
+	registerHandler(method.CreateIndexMethod, createIndexHandler)
+	registerHandler(method.DropIndexMethod, dropIndexHandler)
+	registerHandler(method.BeginTransactionMethod, beginTransactionHandler)
+	registerHandler(method.CommitTransactionMethod, commitTransactionHandler)
+	registerHandler(method.RollbackTransactionMethod, rollbackTransactionHandler)
 	registerHandler(method.SubscribeToMetricUpdates, subscribeToMetricUpdates)
 	registerHandler(method.UnsubscribeFromMetricUpdates, unsubscribeFromMetricUpdates)
 }
@@ -75,6 +95,41 @@ func getTableName(request map[string]interface{}) (string, error) {
 	return getString(request, "tableName")
 }
 
+func getTransactionId(request map[string]interface{}) (*string, error) {
+	return getOptionalString(request, "transactionId")
+}
+
+// getTransactionTable returns the database and table a request in a transaction is addressed to, nil if it has none
+func getTransactionTable(request map[string]interface{}) (*string, *string, error) {
+	name, err := getOptionalString(request, "name")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tableName, err := getOptionalString(request, "tableName")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return name, tableName, nil
+}
+
+func getOptionalString(request map[string]interface{}, key string) (*string, error) {
+	if request[key] == nil {
+		return nil, nil
+	}
+
+	s, err := getString(request, key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
 func shutdownHandler(a *Api, _ *websocket.Conn, _ map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
 	a.shutdown()
 	return nil, nil
@@ -195,7 +250,7 @@ func deleteTableInDatabaseHandler(a *Api, _ *websocket.Conn, request map[string]
 	return a.idb.DeleteTableInDatabase(name, tableName)
 }
 
-func getFromDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+func getFromDatabaseTableHandler(a *Api, conn *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
 	name, err := getDatabaseName(request)
 
 	if err != nil {
@@ -221,10 +276,103 @@ func getFromDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]i
 		return nil, err
 	}
 
+	if stream, _ := request["stream"].(bool); stream {
+		return streamFromDatabaseTable(a, conn, request, name, tableName, *parsedRequest)
+	}
+
 	return a.idb.GetFromDatabaseTable(name, tableName, *parsedRequest)
 }
 
-func insertToDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+// streamFromDatabaseTable sends the results in chunks with the requestId of the request, the returned final chunk closes the stream
+func streamFromDatabaseTable(a *Api, conn *websocket.Conn, request map[string]interface{}, name string, tableName string, parsedRequest table.Request) (any, error) {
+	chunkSize := util.DefaultChunkSize
+
+	if request["chunkSize"] != nil {
+		size, isNumber := request["chunkSize"].(float64)
+
+		if !isNumber || size < 1 {
+			return nil, e.IsNotANumber("chunkSize")
+		}
+
+		chunkSize = int(size)
+	}
+
+	requestId := int64(request["requestId"].(float64))
+
+	return a.idb.StreamFromDatabaseTable(name, tableName, parsedRequest, chunkSize, func(chunk response.GetFromDatabaseTableChunkResponse) error {
+		m, err := util.ToMap(chunk)
+
+		if err != nil {
+			return err
+		}
+
+		if a.sendRequestResponse(conn, requestId, m) {
+			return e.StreamWasClosed()
+		}
+
+		return nil
+	})
+}
+
+func countFromDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.Request
+	err = util.ToStruct(request["request"], &req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parsedRequest, err := parse.Request(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.CountFromDatabaseTable(name, tableName, *parsedRequest)
+}
+
+func existsInDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var req models.Request
+	err = util.ToStruct(request["request"], &req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parsedRequest, err := parse.Request(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.ExistsInDatabaseTable(name, tableName, *parsedRequest)
+}
+
+func openCursorHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
 	name, err := getDatabaseName(request)
 
 	if err != nil {
@@ -237,6 +385,61 @@ func insertToDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]
 		return nil, err
 	}
 
+	ttl := defaultCursorTtl
+
+	if request["ttl"] != nil {
+		seconds, isNumber := request["ttl"].(float64)
+
+		if !isNumber || seconds <= 0 {
+			return nil, e.IsNotANumber("ttl")
+		}
+
+		ttl = time.Duration(seconds * float64(time.Second))
+	}
+
+	var req models.Request
+	err = util.ToStruct(request["request"], &req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parsedRequest, err := parse.Request(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.OpenCursor(name, tableName, *parsedRequest, ttl)
+}
+
+func fetchCursorHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	cursorId, err := getString(request, "cursorId")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.FetchCursor(cursorId)
+}
+
+func closeCursorHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	cursorId, err := getString(request, "cursorId")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.CloseCursor(cursorId)
+}
+
+func insertToDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	transactionId, err := getTransactionId(request)
+
+	if err != nil {
+		return nil, err
+	}
+
 	var o map[string]json.RawMessage
 	err = util.ToStruct(request["object"], &o)
 
@@ -244,10 +447,32 @@ func insertToDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]
 		return nil, err
 	}
 
+	if transactionId != nil {
+		name, tableName, err := getTransactionTable(request)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return a.idb.InsertToDatabaseTableInTransaction(*transactionId, name, tableName, o)
+	}
+
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return a.idb.InsertToDatabaseTable(name, tableName, o)
 }
 
-func removeFromDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+func bulkInsertToDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
 	name, err := getDatabaseName(request)
 
 	if err != nil {
@@ -260,6 +485,23 @@ func removeFromDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[strin
 		return nil, err
 	}
 
+	var objects []map[string]json.RawMessage
+	err = util.ToStruct(request["objects"], &objects)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.BulkInsertToDatabaseTable(name, tableName, objects)
+}
+
+func removeFromDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	transactionId, err := getTransactionId(request)
+
+	if err != nil {
+		return nil, err
+	}
+
 	var req models.Request
 	err = util.ToStruct(request["request"], &req)
 
@@ -273,10 +515,71 @@ func removeFromDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[strin
 		return nil, err
 	}
 
+	if transactionId != nil {
+		name, tableName, err := getTransactionTable(request)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return a.idb.RemoveFromDatabaseTableInTransaction(*transactionId, name, tableName, *parsedRequest)
+	}
+
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return a.idb.RemoveFromDatabaseTable(name, tableName, *parsedRequest)
 }
 
 func updateInDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	transactionId, err := getTransactionId(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var o map[string]json.RawMessage
+	err = util.ToStruct(request["object"], &o)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if transactionId != nil {
+		name, tableName, err := getTransactionTable(request)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return a.idb.UpdateInDatabaseTableInTransaction(*transactionId, name, tableName, o)
+	}
+
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.UpdateInDatabaseTable(name, tableName, o)
+}
+
+func upsertToDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
 	name, err := getDatabaseName(request)
 
 	if err != nil {
@@ -296,7 +599,179 @@ func updateInDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]
 		return nil, err
 	}
 
-	return a.idb.UpdateInDatabaseTable(name, tableName, o)
+	return a.idb.UpsertToDatabaseTable(name, tableName, o)
+}
+
+func updateWhereHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var u models.UpdateWhere
+	err = util.ToStruct(request["query"], &u.Query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = util.ToStruct(request["update"], &u.Update)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q, updates, err := parse.UpdateWhere(u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.UpdateWhereInDatabaseTable(name, tableName, *q, updates)
+}
+
+func compactDatabaseTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.CompactDatabaseTable(name, tableName)
+}
+
+func alterTableHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []models.AlterTableChange
+	err = util.ToStruct(request["changes"], &changes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parsedChanges, err := parse.AlterTableChanges(changes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.AlterTable(name, tableName, parsedChanges)
+}
+
+func createIndexHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fieldName, err := getString(request, "fieldName")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.CreateIndex(name, tableName, fieldName)
+}
+
+func dropIndexHandler(a *Api, _ *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fieldName, err := getString(request, "fieldName")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.idb.DropIndex(name, tableName, fieldName)
+}
+
+func beginTransactionHandler(a *Api, conn *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	name, err := getDatabaseName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableName, err := getTableName(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := a.idb.BeginTransaction(name, tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	a.addTransaction(conn, r.TransactionId)
+
+	return r, nil
+}
+
+func commitTransactionHandler(a *Api, conn *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	transactionId, err := getString(request, "transactionId")
+
+	if err != nil {
+		return nil, err
+	}
+
+	a.removeTransaction(conn, transactionId)
+
+	return a.idb.CommitTransaction(transactionId)
+}
+
+func rollbackTransactionHandler(a *Api, conn *websocket.Conn, request map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
+	transactionId, err := getString(request, "transactionId")
+
+	if err != nil {
+		return nil, err
+	}
+
+	a.removeTransaction(conn, transactionId)
+
+	return a.idb.RollbackTransaction(transactionId)
 }
 
 func subscribeToMetricUpdates(a *Api, conn *websocket.Conn, _ map[string]interface{}, _ map[string]json.RawMessage) (any, error) {
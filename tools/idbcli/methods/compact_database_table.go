@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package methods
+
+import (
+	"fmt"
+	"github.com/lucasl0st/InfiniteDB/client"
+)
+
+func init() {
+	Methods = append(Methods, Method{
+		Name: "compact_database_table",
+		Arguments: []Argument{
+			{
+				Name:        "name",
+				Description: "Name of the database",
+			},
+			{
+				Name:        "table-name",
+				Description: "Name of the table",
+			},
+		},
+		Run: runCompactDatabaseTable,
+	})
+}
+
+func runCompactDatabaseTable(c *client.Client, args []string) error {
+	name := args[0]
+	tableName := args[1]
+
+	res, err := c.CompactDatabaseTable(name, tableName)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s, %v events before, %v events after\n", res.Message, res.EventsBefore, res.EventsAfter)
+
+	return nil
+}
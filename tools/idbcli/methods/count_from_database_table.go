@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package methods
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/lucasl0st/InfiniteDB/client"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+)
+
+func init() {
+	Methods = append(Methods, Method{
+		Name: "count_from_database_table",
+		Arguments: []Argument{
+			{
+				Name:        "name",
+				Description: "Name of the database",
+			},
+			{
+				Name:        "table-name",
+				Description: "Name of the table",
+			},
+		},
+		RawArguments: []Argument{
+			{
+				Name:        "request",
+				Description: "Request as json",
+			},
+		},
+		Run: runCountFromDatabaseTable,
+	})
+}
+
+func runCountFromDatabaseTable(c *client.Client, args []string) error {
+	name := args[0]
+	tableName := args[1]
+
+	var r request.Request
+
+	err := json.Unmarshal([]byte(args[2]), &r)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := c.CountFromDatabaseTable(name, tableName, r)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%v objects\n", res.Count)
+
+	return nil
+}
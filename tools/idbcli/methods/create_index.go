@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package methods
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lucasl0st/InfiniteDB/client"
+	"time"
+)
+
+func init() {
+	Methods = append(Methods, Method{
+		Name: "create_index",
+		Arguments: []Argument{
+			{
+				Name:        "name",
+				Description: "Name of the database",
+			},
+			{
+				Name:        "table-name",
+				Description: "Name of the table",
+			},
+			{
+				Name:        "field-name",
+				Description: "Name of the field to index",
+			},
+		},
+		Run: runCreateIndex,
+	})
+}
+
+func runCreateIndex(c *client.Client, args []string) error {
+	name := args[0]
+	tableName := args[1]
+	fieldName := args[2]
+
+	res, err := c.CreateIndex(name, tableName, fieldName)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s, %v objects to index\n", res.Message, res.Progress.Total)
+
+	for {
+		time.Sleep(time.Second)
+
+		table, err := c.GetDatabaseTable(name, tableName)
+
+		if err != nil {
+			return err
+		}
+
+		progress, ok := table.IndexBuilds[fieldName]
+
+		if !ok {
+			if f, ok := table.Fields[fieldName]; !ok || f.Indexed == nil || !*f.Indexed {
+				return errors.New("index build was cancelled")
+			}
+
+			break
+		}
+
+		fmt.Printf("indexed %v/%v objects\n", progress.Processed, progress.Total)
+	}
+
+	fmt.Println("Built index")
+
+	return nil
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package methods
+
+import (
+	"fmt"
+	"github.com/lucasl0st/InfiniteDB/client"
+)
+
+func init() {
+	Methods = append(Methods, Method{
+		Name: "drop_index",
+		Arguments: []Argument{
+			{
+				Name:        "name",
+				Description: "Name of the database",
+			},
+			{
+				Name:        "table-name",
+				Description: "Name of the table",
+			},
+			{
+				Name:        "field-name",
+				Description: "Name of the indexed field",
+			},
+		},
+		Run: runDropIndex,
+	})
+}
+
+func runDropIndex(c *client.Client, args []string) error {
+	name := args[0]
+	tableName := args[1]
+	fieldName := args[2]
+
+	res, err := c.DropIndex(name, tableName, fieldName)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(res.Message)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2023 Lucas Pape
+ */
+
+package methods
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/lucasl0st/InfiniteDB/client"
+	"github.com/lucasl0st/InfiniteDB/models/request"
+	"github.com/lucasl0st/InfiniteDB/models/response"
+	"os"
+	"strings"
+)
+
+func init() {
+	Methods = append(Methods, Method{
+		Name: "explain",
+		Arguments: []Argument{
+			{
+				Name:        "name",
+				Description: "Name of the database",
+			},
+			{
+				Name:        "table-name",
+				Description: "Name of the table",
+			},
+		},
+		RawArguments: []Argument{
+			{
+				Name:        "request",
+				Description: "Request as json",
+			},
+		},
+		Run: runExplain,
+	})
+}
+
+func runExplain(c *client.Client, args []string) error {
+	name := args[0]
+	tableName := args[1]
+
+	var r request.Request
+
+	err := json.Unmarshal([]byte(args[2]), &r)
+
+	if err != nil {
+		return err
+	}
+
+	r.Explain = true
+
+	res, err := c.GetFromDatabaseTable(name, tableName, r)
+
+	if err != nil {
+		return err
+	}
+
+	if res.Explain == nil {
+		return errors.New("database did not return a plan")
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+
+	t.AppendHeader(table.Row{"Stage", "Access", "Estimate", "Before", "After", "Duration"})
+
+	appendExplainStage(t, *res.Explain, 0)
+
+	t.Render()
+
+	return nil
+}
+
+func appendExplainStage(t table.Writer, s response.ExplainStage, depth int) {
+	stage := strings.Repeat("  ", depth) + s.Stage
+
+	switch {
+	case len(s.Operator) > 0:
+		stage += fmt.Sprintf(" %s %s %s", s.Field, s.Operator, string(s.Value))
+	case len(s.Function) > 0:
+		stage += " " + s.Function
+	case len(s.Field) > 0:
+		stage += " " + s.Field
+	}
+
+	access := s.Access
+
+	if len(s.Index) > 0 {
+		access += " (" + s.Index + ")"
+	}
+
+	var estimate any = ""
+
+	if s.Estimate != nil {
+		estimate = *s.Estimate
+	}
+
+	t.AppendRow(table.Row{stage, access, estimate, s.Before, s.After, s.Duration})
+
+	for _, child := range s.Stages {
+		appendExplainStage(t, child, depth+1)
+	}
+}
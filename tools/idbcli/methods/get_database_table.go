@@ -5,6 +5,7 @@
 package methods
 
 import (
+	"fmt"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/lucasl0st/InfiniteDB/client"
 	"os"
@@ -43,12 +44,16 @@ func runGetDatabaseTable(c *client.Client, args []string) error {
 	t.AppendHeader(table.Row{"Field", "Type", "Indexed", "Unique", "Null"})
 
 	for fieldName, field := range res.Fields {
-		indexed := false
+		var indexed any = false
 
 		if field.Indexed != nil && *field.Indexed {
 			indexed = true
 		}
 
+		if progress, ok := res.IndexBuilds[fieldName]; ok {
+			indexed = fmt.Sprintf("building %v/%v", progress.Processed, progress.Total)
+		}
+
 		unique := false
 
 		if field.Unique != nil && *field.Unique {
@@ -20,6 +20,8 @@ type Import struct {
 
 	currentDatabase string
 	currentTable    string
+
+	objects []map[string]json.RawMessage
 }
 
 func New(c *client.Client, reader bufio.Scanner) *Import {
@@ -47,7 +49,7 @@ func (i *Import) Import() error {
 		}
 	}
 
-	return nil
+	return i.flushObjects()
 }
 
 const (
@@ -56,11 +58,25 @@ const (
 	objectPrefix   = "//Object:"
 )
 
+const objectBatchSize = 1000
+
 func (i *Import) processLine(l string) error {
 
 	if strings.HasPrefix(l, databasePrefix) {
+		err := i.flushObjects()
+
+		if err != nil {
+			return err
+		}
+
 		return i.processDatabase(strings.ReplaceAll(l, databasePrefix, ""))
 	} else if strings.HasPrefix(l, tablePrefix) {
+		err := i.flushObjects()
+
+		if err != nil {
+			return err
+		}
+
 		return i.processTable(strings.ReplaceAll(l, tablePrefix, ""))
 	} else if strings.HasPrefix(l, objectPrefix) {
 		return i.processObject(strings.ReplaceAll(l, objectPrefix, ""))
@@ -130,11 +146,33 @@ func (i *Import) processObject(s string) error {
 		return err
 	}
 
-	_, err = i.c.InsertToDatabaseTable(i.currentDatabase, i.currentTable, object)
+	i.objects = append(i.objects, object)
+
+	if len(i.objects) >= objectBatchSize {
+		return i.flushObjects()
+	}
+
+	return nil
+}
+
+func (i *Import) flushObjects() error {
+	if len(i.objects) == 0 {
+		return nil
+	}
+
+	r, err := i.c.BulkInsertToDatabaseTable(i.currentDatabase, i.currentTable, i.objects)
 
 	if err != nil {
 		return err
 	}
 
+	i.objects = nil
+
+	for _, result := range r.Results {
+		if result.Error != nil {
+			return errors.New(fmt.Sprintf("error inserting object into table %s: %s", i.currentTable, *result.Error))
+		}
+	}
+
 	return nil
 }